@@ -0,0 +1,169 @@
+package toil
+
+
+import (
+	"context"
+	"errors"
+)
+
+
+// ErrNoCheckpointer is returned by the methods of the Checkpointer that
+// CheckpointerFromContext returns when the Group was not given one. (See WithCheckpointer.)
+var ErrNoCheckpointer = errors.New("toil: no checkpointer: the Group was not given one")
+
+
+// ErrUnnamedCheckpointer is returned by the methods of the Checkpointer that
+// CheckpointerFromContext returns when the Group or the toiler was not given a name.
+// (See WithCheckpointer.)
+var ErrUnnamedCheckpointer = errors.New("toil: no checkpointer: both the Group and the toiler must be given a name")
+
+
+// Checkpointer is an interface that wraps the Save and Load methods.
+//
+// A toiler uses a Checkpointer to save its progress (i.e., a checkpoint), so that if it
+// is restarted (for example, by a RestartPolicy, after it panic()ed) it can resume from
+// where it left off, rather than starting over.
+//
+// Save saves `data` as the checkpoint for `key`, replacing any checkpoint already saved
+// for `key`.
+//
+// Load returns the checkpoint last saved for `key`. If no checkpoint has been saved for
+// `key`, then it returns nil (and no error).
+//
+// A Group is given a Checkpointer with WithCheckpointer. A toiler that is a ContextToiler
+// gets its Checkpointer from the context passed to its ToilContext method, with
+// CheckpointerFromContext. For example:
+//
+//	func (toiler *awesomeToiler) ToilContext(ctx context.Context) {
+//		checkpointer := toil.CheckpointerFromContext(ctx)
+//	
+//		data, err := checkpointer.Load("offset")
+//	
+//		//@TODO: Resume from the offset in data.
+//	
+//		for {
+//			//@TODO: Do a unit of work here.
+//	
+//			err := checkpointer.Save("offset", data)
+//		}
+//	}
+//
+// (See NewMemoryCheckpointer and NewFileCheckpointer.)
+type Checkpointer interface {
+	Save(key string, data []byte) error
+	Load(key string) ([]byte, error)
+}
+
+
+// WithCheckpointer returns a GroupOption that makes the Group give its toilers
+// `checkpointer`. (See Checkpointer.)
+//
+// Each toiler gets its own keys. I.e., the keys a toiler uses are prefixed with the name
+// of the Group and the name of the toiler. So, give the Group a name, and give the toilers
+// unique names. (See Name.)
+//
+// NOTE that a toiler only gets `checkpointer` if both the Group and the toiler were given
+// a name. (Otherwise, the methods of the Checkpointer that CheckpointerFromContext returns
+// return ErrUnnamedCheckpointer.) This is because the names
+// that are used if none are given are not stable (across restarts of the process) or
+// unique, so the toiler could resume from the checkpoints of another toiler.
+func WithCheckpointer(checkpointer Checkpointer) GroupOption {
+	return checkpointerOption{
+		checkpointer:checkpointer,
+	}
+}
+
+
+type checkpointerOption struct {
+	checkpointer Checkpointer
+}
+
+
+func (option checkpointerOption) applyGroupOption(config *groupConfig) {
+	config.checkpointer = option.checkpointer
+}
+
+
+type checkpointerContextKey struct{}
+
+
+// CheckpointerFromContext returns the Checkpointer in the context passed to a toiler's
+// ToilContext method.
+//
+// CheckpointerFromContext never returns nil. If there is no Checkpointer in the context,
+// then it returns a Checkpointer whose methods return an error that says why: either
+// ErrNoCheckpointer (the Group was not given one) or ErrUnnamedCheckpointer (the Group
+// or the toiler was not given a name).
+func CheckpointerFromContext(ctx context.Context) Checkpointer {
+	checkpointer, ok := ctx.Value(checkpointerContextKey{}).(Checkpointer)
+	if !ok {
+		return noCheckpointer{
+			err:ErrNoCheckpointer,
+		}
+	}
+
+	return checkpointer
+}
+
+
+// withCheckpointer puts the Checkpointer for a registered toiler (if the daemon has one)
+// into the context. If the daemon or the toiler was not given a name, then it puts a
+// Checkpointer that only returns ErrUnnamedCheckpointer into the context instead.
+func (daemon *internalGroupDaemon) withCheckpointer(ctx context.Context, registration *registeredToiler) context.Context {
+	if nil == daemon.config.checkpointer {
+		return ctx
+	}
+
+	// NOTE that the explicitly given names are used (rather than the name methods,
+	// which fall back to names that are not stable or unique).
+	groupName  := daemon.config.name
+	toilerName := registration.config.name
+	if "" == groupName || "" == toilerName {
+		checkpointer := noCheckpointer{
+			err:ErrUnnamedCheckpointer,
+		}
+
+		return context.WithValue(ctx, checkpointerContextKey{}, checkpointer)
+	}
+
+	checkpointer := prefixedCheckpointer{
+		checkpointer:daemon.config.checkpointer,
+		prefix:groupName + "/" + toilerName + "/",
+	}
+
+	return context.WithValue(ctx, checkpointerContextKey{}, checkpointer)
+}
+
+
+// prefixedCheckpointer is a Checkpointer that prefixes all the keys.
+type prefixedCheckpointer struct {
+	checkpointer Checkpointer
+	prefix       string
+}
+
+
+func (checkpointer prefixedCheckpointer) Save(key string, data []byte) error {
+	return checkpointer.checkpointer.Save(checkpointer.prefix+key, data)
+}
+
+
+func (checkpointer prefixedCheckpointer) Load(key string) ([]byte, error) {
+	return checkpointer.checkpointer.Load(checkpointer.prefix+key)
+}
+
+
+// noCheckpointer is a Checkpointer whose methods only return `err`. It stands in for
+// a Checkpointer that a toiler cannot have.
+type noCheckpointer struct {
+	err error
+}
+
+
+func (checkpointer noCheckpointer) Save(key string, data []byte) error {
+	return checkpointer.err
+}
+
+
+func (checkpointer noCheckpointer) Load(key string) ([]byte, error) {
+	return nil, checkpointer.err
+}
@@ -0,0 +1,245 @@
+package toil
+
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+
+// checkpointingToiler returns a toiler that counts from its last checkpoint up to 10,
+// saving a checkpoint after each step, and panic()s (once) when it gets to `panicAt`.
+//
+// It records (in `started`) what it resumed from at the start of each run.
+func checkpointingToiler(t *testing.T, panicAt int, started *[]int) ContextToiler {
+	panicked := false
+
+	return ContextToilerFunc(func(ctx context.Context) {
+		checkpointer := CheckpointerFromContext(ctx)
+
+		n := 0
+
+		data, err := checkpointer.Load("n")
+		if nil != err {
+			t.Errorf("Did not expect an error, but actually got one: (%T) %v", err, err)
+			return
+		}
+		if nil != data {
+			n, err = strconv.Atoi(string(data))
+			if nil != err {
+				t.Errorf("Did not expect an error, but actually got one: (%T) %v", err, err)
+				return
+			}
+		}
+
+		*started = append(*started, n)
+
+		for n < 10 {
+			if n == panicAt && !panicked {
+				panicked = true
+				panic("apple")
+			}
+
+			n++
+
+			if err := checkpointer.Save("n", []byte(strconv.Itoa(n))); nil != err {
+				t.Errorf("Did not expect an error, but actually got one: (%T) %v", err, err)
+				return
+			}
+		}
+	})
+}
+
+
+func TestCheckpointerResumesAfterPanic(t *testing.T) {
+
+	fileCheckpointer, err := NewFileCheckpointer(t.TempDir())
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	tests := []struct{
+		Name         string
+		Checkpointer Checkpointer
+	}{
+		{
+			Name:"memory",
+			Checkpointer:NewMemoryCheckpointer(),
+		},
+		{
+			Name:"file",
+			Checkpointer:fileCheckpointer,
+		},
+	}
+
+	for testNumber, test := range tests {
+
+		var started []int
+
+		group := NewGroup(Name("batch"), Isolate, RestartOnPanic, WithCheckpointer(test.Checkpointer))
+		group.RegisterWith(checkpointingToiler(t, 7, &started), Name("counter"))
+
+		returned, panicValue := toilWithin(group, 5*time.Second)
+		if !returned {
+			t.Errorf("For test #%d (%s), expected Toil() to return, but it did not. (Panic value: %v)", testNumber, test.Name, panicValue)
+			continue
+		}
+
+		if expected, actual := 2, len(started); expected != actual {
+			t.Errorf("For test #%d (%s), expected the toiler to have run %d times, but actually was %d.", testNumber, test.Name, expected, actual)
+			continue
+		}
+		if expected, actual := 0, started[0]; expected != actual {
+			t.Errorf("For test #%d (%s), expected the first run to start from %d, but actually was %d.", testNumber, test.Name, expected, actual)
+		}
+		if expected, actual := 7, started[1]; expected != actual {
+			t.Errorf("For test #%d (%s), expected the restarted run to resume from %d, but actually was %d.", testNumber, test.Name, expected, actual)
+		}
+	}
+}
+
+
+func TestCheckpointerKeysPerToiler(t *testing.T) {
+
+	checkpointer := NewMemoryCheckpointer()
+
+	group := NewGroup(Name("workers"), WithCheckpointer(checkpointer))
+
+	for _,name := range []string{"apple", "banana"} {
+		name := name
+		group.RegisterWith(ContextToilerFunc(func(ctx context.Context) {
+			CheckpointerFromContext(ctx).Save("key", []byte(name))
+		}), Name(name))
+	}
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Fatalf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+	}
+
+	for _,name := range []string{"apple", "banana"} {
+		data, err := checkpointer.Load("workers/" + name + "/key")
+		if nil != err {
+			t.Errorf("Did not expect an error, but actually got one: (%T) %v", err, err)
+			continue
+		}
+		if expected, actual := name, string(data); expected != actual {
+			t.Errorf("Expected the checkpoint of %q to be %q, but actually was %q.", name, expected, actual)
+		}
+	}
+
+	if data, err := checkpointer.Load("missing"); nil != data || nil != err {
+		t.Errorf("Expected loading a missing checkpoint to return nil and no error, but actually was %q and %v.", data, err)
+	}
+}
+
+
+func TestCheckpointerFromContextNone(t *testing.T) {
+
+	checkpointer := CheckpointerFromContext(context.Background())
+	if nil == checkpointer {
+		t.Fatalf("Expected a Checkpointer (that returns errors), but actually got nil.")
+	}
+
+	if data, err := checkpointer.Load("key"); nil != data || ErrNoCheckpointer != err {
+		t.Errorf("Expected Load() to return nil and error %v, but actually was %q and %v.", ErrNoCheckpointer, data, err)
+	}
+	if expected, actual := ErrNoCheckpointer, checkpointer.Save("key", []byte("apple")); expected != actual {
+		t.Errorf("Expected Save() to return error %v, but actually was %v.", expected, actual)
+	}
+}
+
+
+func TestCheckpointerNeedsNames(t *testing.T) {
+
+	tests := []struct{
+		Name          string
+		GroupOptions  []GroupOption
+		ToilerOptions []RegisterOption
+		Expected      error
+	}{
+		{
+			Name:"named",
+			GroupOptions:[]GroupOption{Name("workers")},
+			ToilerOptions:[]RegisterOption{Name("worker")},
+			Expected:nil,
+		},
+		{
+			Name:"unnamed group",
+			ToilerOptions:[]RegisterOption{Name("worker")},
+			Expected:ErrUnnamedCheckpointer,
+		},
+		{
+			Name:"unnamed toiler",
+			GroupOptions:[]GroupOption{Name("workers")},
+			Expected:ErrUnnamedCheckpointer,
+		},
+	}
+
+	for testNumber, test := range tests {
+
+		var got error
+
+		group := NewGroup(append(test.GroupOptions, WithCheckpointer(NewMemoryCheckpointer()))...)
+		group.RegisterWith(ContextToilerFunc(func(ctx context.Context) {
+			got = CheckpointerFromContext(ctx).Save("key", []byte("apple"))
+		}), test.ToilerOptions...)
+
+		returned, panicValue := toilWithin(group, 5*time.Second)
+		if !returned {
+			t.Errorf("For test #%d (%s), expected Toil() to return, but it did not. (Panic value: %v)", testNumber, test.Name, panicValue)
+			continue
+		}
+
+		if expected, actual := test.Expected, got; expected != actual {
+			t.Errorf("For test #%d (%s), expected Save() to return error %v, but actually was %v.", testNumber, test.Name, expected, actual)
+		}
+	}
+}
+
+
+func TestFileCheckpointerKeys(t *testing.T) {
+
+	dir := t.TempDir()
+
+	checkpointer, err := NewFileCheckpointer(dir)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	keys := []string{"batch/importer/offset", "c:offset", "Offset", "offset", "../offset", ""}
+
+	for _,key := range keys {
+		if err := checkpointer.Save(key, []byte(key + "!")); nil != err {
+			t.Errorf("For key %q, did not expect an error, but actually got one: (%T) %v", key, err, err)
+		}
+	}
+
+	for _,key := range keys {
+		data, err := checkpointer.Load(key)
+		if nil != err {
+			t.Errorf("For key %q, did not expect an error, but actually got one: (%T) %v", key, err, err)
+			continue
+		}
+		if expected, actual := key + "!", string(data); expected != actual {
+			t.Errorf("For key %q, expected the checkpoint to be %q, but actually was %q.", key, expected, actual)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if expected, actual := len(keys), len(entries); expected != actual {
+		t.Errorf("Expected %d files in the directory, but actually was %d.", expected, actual)
+	}
+	for _,entry := range entries {
+		if strings.ContainsAny(entry.Name(), `:/\`) {
+			t.Errorf("Did not expect the file name %q to contain ':', '/' or '\\', but it did.", entry.Name())
+		}
+	}
+}
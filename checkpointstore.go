@@ -0,0 +1,126 @@
+package toil
+
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+
+// NewMemoryCheckpointer returns a Checkpointer that keeps the checkpoints in memory.
+//
+// The checkpoints survive a toiler being restarted, but not the process exiting.
+func NewMemoryCheckpointer() Checkpointer {
+	checkpointer := memoryCheckpointer{
+		checkpoints:map[string][]byte{},
+	}
+
+	return &checkpointer
+}
+
+
+type memoryCheckpointer struct {
+	mutex       sync.Mutex
+	checkpoints map[string][]byte
+}
+
+
+func (checkpointer *memoryCheckpointer) Save(key string, data []byte) error {
+	checkpointer.mutex.Lock()
+	defer checkpointer.mutex.Unlock()
+
+	checkpointer.checkpoints[key] = append([]byte(nil), data...)
+
+	return nil
+}
+
+
+func (checkpointer *memoryCheckpointer) Load(key string) ([]byte, error) {
+	checkpointer.mutex.Lock()
+	defer checkpointer.mutex.Unlock()
+
+	data, ok := checkpointer.checkpoints[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return append([]byte(nil), data...), nil
+}
+
+
+// NewFileCheckpointer returns a Checkpointer that keeps each checkpoint in its own file,
+// in the directory `dir`. The directory is created if it does not exist.
+//
+// The checkpoints survive the process exiting (or crashing). A checkpoint is written
+// to a temporary file, which is then renamed, so that a crash while saving a checkpoint
+// does not corrupt the checkpoint saved before it.
+func NewFileCheckpointer(dir string) (Checkpointer, error) {
+	if err := os.MkdirAll(dir, 0755); nil != err {
+		return nil, err
+	}
+
+	checkpointer := fileCheckpointer{
+		dir:dir,
+	}
+
+	return &checkpointer, nil
+}
+
+
+type fileCheckpointer struct {
+	dir string
+}
+
+
+// path returns the path of the file of the checkpoint for `key`.
+//
+// NOTE that the key is hex encoded, so that the file name is safe on every platform,
+// and no two keys have the same file name, even on a case-insensitive file system.
+// (For example, on Windows, a ':' in the file name would write to an alternate data
+// stream.)
+func (checkpointer *fileCheckpointer) path(key string) string {
+	return filepath.Join(checkpointer.dir, hex.EncodeToString([]byte(key)) + ".checkpoint")
+}
+
+
+func (checkpointer *fileCheckpointer) Save(key string, data []byte) error {
+	file, err := os.CreateTemp(checkpointer.dir, ".checkpoint-*")
+	if nil != err {
+		return err
+	}
+	defer os.Remove(file.Name()) // NOTE that this fails (harmlessly) after the rename.
+
+	if _, err := file.Write(data); nil != err {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); nil != err {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); nil != err {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), checkpointer.path(key)); nil != err {
+		return err
+	}
+
+	// NOTE that the directory is synced, so that the rename itself is not lost if the
+	// operating system crashes.
+	return syncDir(checkpointer.dir)
+}
+
+
+func (checkpointer *fileCheckpointer) Load(key string) ([]byte, error) {
+	data, err := os.ReadFile(checkpointer.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return data, err
+}
@@ -0,0 +1,134 @@
+package toil
+
+
+import (
+	"context"
+	"time"
+)
+
+
+// Clock is an interface that wraps the Now, NewTimer, NewTicker and AfterFunc methods.
+//
+// A Group uses its Clock for everything it does that involves time. (Such as timeouts,
+// start rates, staggered starts, heartbeats, the watchdog, etc.) By default a Group uses
+// the real clock (i.e., the time package). A Group can be given a different Clock with
+// WithClock. For example, tests can use a fake clock (such as toiltest.FakeClock), to
+// make them deterministic.
+//
+// Now returns the current time. (Like time.Now.)
+//
+// NewTimer returns a channel that the current time is sent on after `d`, and a stop func.
+// (Like time.NewTimer.)
+//
+// NewTicker returns a channel that the current time is sent on every `d`, and a stop func.
+// (Like time.NewTicker.)
+//
+// AfterFunc calls `fn` in its own goroutine after `d`, and returns a stop func.
+// (Like time.AfterFunc.)
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+	AfterFunc(d time.Duration, fn func()) func() bool
+}
+
+
+// WithClock returns a GroupOption that makes the Group use `clock`, instead of the real
+// clock. (See Clock.)
+func WithClock(clock Clock) GroupOption {
+	return clockOption{
+		clock:clock,
+	}
+}
+
+
+type clockOption struct {
+	clock Clock
+}
+
+
+func (option clockOption) applyGroupOption(config *groupConfig) {
+	if nil == option.clock {
+		return
+	}
+
+	config.clock = option.clock
+}
+
+
+// realClock is the Clock that uses the time package.
+type realClock struct{}
+
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+
+	return timer.C, timer.Stop
+}
+
+
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+
+	return ticker.C, ticker.Stop
+}
+
+
+func (realClock) AfterFunc(d time.Duration, fn func()) func() bool {
+	return time.AfterFunc(d, fn).Stop
+}
+
+
+// withTimeout is like context.WithTimeout, except that it uses `clock`.
+func withTimeout(ctx context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	deadline := clock.Now().Add(timeout)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stopTimer := clock.AfterFunc(timeout, func(){
+		cancel(context.DeadlineExceeded)
+	})
+
+	timeoutCtx := clockTimeoutContext{
+		Context:ctx,
+		deadline:deadline,
+	}
+
+	return timeoutCtx, func() {
+		stopTimer()
+		cancel(context.Canceled)
+	}
+}
+
+
+// clockTimeoutContext is the context withTimeout returns, for a Clock that is not
+// the real clock.
+type clockTimeoutContext struct {
+	context.Context
+	deadline time.Time
+}
+
+
+func (ctx clockTimeoutContext) Deadline() (time.Time, bool) {
+	return ctx.deadline, true
+}
+
+
+// Err returns context.DeadlineExceeded (rather than context.Canceled) if the context
+// was cancelled because the timeout passed.
+func (ctx clockTimeoutContext) Err() error {
+	err := ctx.Context.Err()
+	if nil != err && context.DeadlineExceeded == context.Cause(ctx.Context) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
@@ -0,0 +1,56 @@
+package toil
+
+
+import (
+	"context"
+)
+
+
+// ContextToiler is an interface that wraps the Toil and ToilContext methods.
+//
+// If a toiler is also a ContextToiler, then the Group will call its ToilContext
+// method (rather than its Toil method), with a context that is cancelled when the
+// Group is stopped, or when the toiler's run times out (see Timeout).
+//
+// The ToilContext method should block while it is doing work, and should return
+// (gracefully) when the context is done.
+type ContextToiler interface {
+	Toiler
+	ToilContext(context.Context)
+}
+
+
+// The ContextToilerFunc type is an adapter to allow the use of ordinary functions as context toilers.
+// If fn is a function with the appropriate signature, ContextToilerFunc(fn) is a ContextToiler that calls fn.
+//
+// Example:
+//
+//	func fn(ctx context.Context) {
+//		//@TODO
+//	}
+//	
+//	var toiler ContextToiler = ContextToilerFunc(fn)
+type ContextToilerFunc func(context.Context)
+
+
+// Toil calls fn(context.Background()).
+func (fn ContextToilerFunc) Toil() {
+	fn(context.Background())
+}
+
+
+// ToilContext calls fn(ctx).
+func (fn ContextToilerFunc) ToilContext(ctx context.Context) {
+	fn(ctx)
+}
+
+
+// toil makes the toiler toil, with the context if the toiler supports it.
+func toil(ctx context.Context, toiler Toiler) {
+	if contextToiler, ok := toiler.(ContextToiler); ok {
+		contextToiler.ToilContext(ctx)
+		return
+	}
+
+	toiler.Toil()
+}
@@ -27,6 +27,9 @@ Then create a toil.Group. For example:
 		ToilerGroup = toil.NewGroup()
 	)
 
+(toil.NewGroup returns a toil.Supervisor. Which is a toil.Group that can also be stopped,
+paused, resumed and reloaded, and that reports on its toilers.)
+
 Then register one of more toilers (i.e., types that implement the toil.Toiler interface)
 with the toiler group. For example:
 
@@ -63,13 +66,14 @@ Observers
 A toiler's Toil method can finish in one of two ways. Either it will return gracefully, or
 it will panic().
 
-The toiler group is OK with either.
+The toiler group is OK with either. (Although what it does when a toiler's Toil method
+panic()s depends on its panic policy. See "Panic Policies" below.)
 
 But also, the toiler group provides the toiler with a convenient way of being notified
 of each case.
 
-If a toiler also has a Terminated() method, then the toiler group will call the toiler's
-Terminated() method when the toiler's Toil() method has returned gracefully. For example:
+If a toiler also has a ReturnedNotice() method, then the toiler group will call the toiler's
+ReturnedNotice() method when the toiler's Toil() method has returned gracefully. For example:
 
 	type awesomeToiler struct{}
 	
@@ -84,7 +88,7 @@ Terminated() method when the toiler's Toil() method has returned gracefully. For
 		//@TODO: Do work here.
 	}
 	
-	func (toiler *awesomeToiler) Terminated() {
+	func (toiler *awesomeToiler) ReturnedNotice() {
 		//@TODO: Do something with this notification.
 	}
 
@@ -104,12 +108,21 @@ PanickedNotice() method when the toiler's Toil() method has panic()ed. For examp
 		//@TODO: Do work here.
 	}
 	
-	func (toiler *awesomeToiler) PanickedNotice() {
+	func (toiler *awesomeToiler) PanickedNotice(panicValue interface{}) {
 		//@TODO: Do something with this notification.
 	}
 
+If a toiler also has a PanickedErrorNotice() method, then the toiler group will call it
+(with a *toil.PanicError) when the toiler's Toil() method has panic()ed. Unlike the
+PanickedNotice() method, which only gets the panic value, a *toil.PanicError also carries
+the stack trace of the panic, the toiler that panic()ed, and when it happened. For example:
+
+	func (toiler *awesomeToiler) PanickedErrorNotice(err *toil.PanicError) {
+		log.Printf("%v\n%s", err, err.Stack)
+	}
+
 And of course, a toiler can take advantage of both of these notifications and have
-both a PanickedNotice() and Terminated() method. For example:
+both a PanickedNotice() and ReturnedNotice() method. For example:
 
 	type awesomeToiler struct{}
 	
@@ -124,13 +137,246 @@ both a PanickedNotice() and Terminated() method. For example:
 		//@TODO: Do work here.
 	}
 	
-	func (toiler *awesomeToiler) PanickedNotice() {
+	func (toiler *awesomeToiler) PanickedNotice(panicValue interface{}) {
 		//@TODO: Do something with this notification.
 	}
 	
-	func (toiler *awesomeToiler) Terminated() {
+	func (toiler *awesomeToiler) ReturnedNotice() {
+		//@TODO: Do something with this notification.
+	}
+
+If a toiler also has a RecoveredNotice() method, then the toiler group will call the toiler's
+RecoveredNotice() method when the toiler's Toil() method has panic()ed and the toiler group
+recovered from that panic (rather than propagating it). Whether the toiler group recovers
+from a panic is decided by its panic policy. (See "Panic Policies" below.) For example:
+
+	func (toiler *awesomeToiler) RecoveredNotice(panicValue interface{}) {
 		//@TODO: Do something with this notification.
 	}
 
+By default, each of these notices is delivered in its own goroutine. So a notice may be
+delivered after the toiler group's Toil method has returned. If that is a problem (for
+example, in tests or in shutdown code), then the toiler group can be made to deliver the
+notices synchronously instead. For example:
+
+	var (
+		ToilerGroup = toil.NewGroup(toil.SynchronousNotices(5 * time.Second))
+	)
+
+Panic Policies
+
+By default, if a toiler's Toil method panic()s, then the toiler group's Toil method will
+panic() too (on the same panic value). What the toiler group does instead can be chosen
+by giving it a PanicPolicy. For example:
+
+	var (
+		ToilerGroup = toil.NewGroup(toil.Isolate)
+	)
+
+The panic policies are:
+
+	toil.Propagate       // The toiler group's Toil method panic()s. (This is the default.)
+	toil.Isolate         // The panic is recovered from and recorded, and the rest of the toilers keep toiling.
+	toil.StopGroup       // The panic is recovered from and recorded, and the rest of the toilers are stopped.
+	toil.ExitProcess(1)  // The panic is logged, and the process exits (with exit code 1).
+
+The recorded panics can be gotten from the toiler group's Panics method. And with StopGroup,
+the toiler group's Err method returns the panic (as a *toil.PanicError) that stopped it.
+
+A toiler is stopped by calling its Stop method, if it has one. (I.e., if it is a toil.Stopper.)
+All the toilers in a toiler group can also be stopped by calling the toiler group's Stop method.
+
+Contexts and Timeouts
+
+If a toiler also has a ToilContext() method (i.e., it is a toil.ContextToiler), then the
+toiler group will call its ToilContext() method (rather than its Toil() method), with a
+context that is cancelled when the toiler group is stopped.
+
+A toiler can also be registered with a timeout. For example:
+
+	ToilerGroup.RegisterWith(toiler, toil.Timeout(5*time.Minute))
+
+If the toiler has not returned by then, the toiler group stops waiting on it, calls its
+TimedOutNotice() method (if it has one), and applies its panic policy (as if the toiler
+had panic()ed with a *toil.TimeoutError).
+
+Heartbeats and Watchdog
+
+A toiler that is a toil.ContextToiler can get a heartbeat from its context, and beat it
+to let the toiler group know it is still alive. For example:
+
+	func (toiler *awesomeToiler) ToilContext(ctx context.Context) {
+		heartbeat := toil.HeartbeatFromContext(ctx)
+	
+		for {
+			heartbeat.Beat()
+	
+			//@TODO: Do a unit of work here.
+		}
+	}
+
+A toiler group with a watchdog will flag any such toiler whose last heartbeat is too old
+as hung (in the toiler group's Status), and can restart it, or dump the goroutine stacks.
+For example:
+
+	var (
+		ToilerGroup = toil.NewGroup(toil.Watchdog(time.Minute, toil.WatchdogRestart))
+	)
+
+Restarts and Pools
+
+A toiler group can restart toilers (i.e., make them toil again) after they finish, by
+giving it a RestartPolicy. For example:
+
+	var (
+		ToilerGroup = toil.NewGroup(toil.RestartOnPanic)
+	)
+
+Restarts of a toiler that keeps failing are backed off (exponentially, up to a limit), so
+that it does not keep the CPU busy. (See RestartBackoff.)
+
+A toiler group can also be partitioned into named pools, each with its own limit on how
+many of its toilers can be toiling at the same time, and its own RestartPolicy. For example:
+
+	var (
+		ToilerGroup = toil.NewGroup(
+			toil.Pool("io",  toil.MaxConcurrency(32), toil.RestartOnPanic),
+			toil.Pool("cpu", toil.MaxConcurrency(4)),
+		)
+	)
+	
+	// ...
+	
+	ToilerGroup.RegisterWith(toiler, toil.InPool("io"), toil.Priority(2))
+
+Checkpoints
+
+A toiler that is restarted (for example, after it panic()ed) can resume from where it left
+off, rather than starting over, by saving checkpoints of its progress with a Checkpointer.
+A toiler group is given a Checkpointer with WithCheckpointer, and a toiler that is a
+toil.ContextToiler gets it from its context. (Both the toiler group and the toiler must be
+given a name, so that the toiler's checkpoints are its own. If either is not, then the
+Checkpointer's methods return ErrUnnamedCheckpointer.) For example:
+
+	checkpointer, err := toil.NewFileCheckpointer("/var/lib/awesome/checkpoints")
+	
+	// ...
+	
+	var (
+		ToilerGroup = toil.NewGroup(toil.Name("batch"), toil.RestartOnPanic, toil.WithCheckpointer(checkpointer))
+	)
+	
+	// ...
+	
+	ToilerGroup.RegisterWith(toiler, toil.Name("importer"))
+	
+	// ...
+	
+	func (toiler *awesomeToiler) ToilContext(ctx context.Context) {
+		checkpointer := toil.CheckpointerFromContext(ctx)
+	
+		data, err := checkpointer.Load("offset")
+		if nil != err {
+			//@TODO
+		}
+	
+		//@TODO
+	}
+
+Queues
+
+Work (i.e., jobs) that must survive the process restarting can be put in a Queue, such as
+a FileQueue, and done by toilers made with ConsumeQueue. Each task is delivered at least
+once. A task whose handler panic()s is delivered again (and, after too many attempts, is
+dead-lettered), and the panic() is handled by the toiler group, like any other. For example:
+
+	queue, err := toil.NewFileQueue("/var/lib/awesome/jobs.jsonl", toil.MaxAttempts(3))
+	
+	// ...
+	
+	var (
+		ToilerGroup = toil.NewGroup(toil.Pool("jobs", toil.MaxConcurrency(8), toil.RestartOnPanic))
+	)
+	
+	// ...
+	
+	ToilerGroup.RegisterWith(toil.ConsumeQueue(queue, handler), toil.InPool("jobs"))
+	
+	// ...
+	
+	id, err := queue.Enqueue(payload)
+
+Pausing and Events
+
+A toiler group can be paused and resumed (for example, for a maintenance window) with its
+Pause and Resume methods. A single toiler can be paused and resumed with the Registration
+that RegisterWith returns. For example:
+
+	registration := ToilerGroup.RegisterWith(toiler)
+	
+	// ...
+	
+	registration.Pause()
+	
+	// ...
+	
+	registration.Resume()
+
+Pausing a toiler stops it (by cancelling its context) but keeps it registered. Resuming it
+makes it toil again. (A toiler's Stop method is only called when the toiler group is stopped.)
+
+What happens to a toiler group's toilers (started, returned, panicked, paused, etc) can be
+received as Events. For example:
+
+	eventCh := make(chan toil.Event, 64)
+	
+	var (
+		ToilerGroup = toil.NewGroup(toil.Events(eventCh))
+	)
+
+The Events can also be written to a Journal, such as a FileJournal, so that what happened
+can be seen after a process crashes. (See ReadJournal and ReplayJournal.) For example:
+
+	journal, err := toil.NewFileJournal("/var/log/awesome/toil.jsonl")
+	
+	// ...
+	
+	var (
+		ToilerGroup = toil.NewGroup(toil.WithJournal(journal))
+	)
+
+Reloading
+
+A toiler group can make its toilers reload (their configuration) with its Reload method.
+Toilers that have a Reload method (i.e., that are Reloaders) have it called. For example:
+
+	func (toiler *awesomeToiler) Reload(ctx context.Context) error {
+		//@TODO: Reload the configuration here.
+	}
+
+Toilers that do not have a Reload method are instead stopped (by cancelling their context)
+and restarted.
+
+Leader Election
+
+A toiler that must toil on only one replica (of possibly many) at a time can be wrapped
+with LeaderGate, so that it only toils while its replica is the leader, as decided by an
+Elector. For example:
+
+	toiler = toil.LeaderGate(toiler, toil.NewFileElector("/var/run/awesome.lock", 0))
+
+Clocks
+
+A toiler group uses its Clock for everything it does that involves time. By default that is
+the real clock. Tests can instead give it a fake clock, to make them deterministic. For example:
+
+	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	
+	ToilerGroup := toil.NewGroup(toil.WithClock(clock))
+	
+	// ...
+	
+	clock.Advance(time.Minute)
+
 */
 package toil
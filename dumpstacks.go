@@ -0,0 +1,118 @@
+package toil
+
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+)
+
+
+// dumpStacks writes the stacks of the goroutines of this daemon's toilers to `writer`.
+func (daemon *internalGroupDaemon) dumpStacks(writer io.Writer) error {
+	stacks, err := daemon.stacks()
+	if nil != err {
+		return err
+	}
+
+	_, err = writer.Write(stacks)
+	return err
+}
+
+
+// dumpStacksInBackground takes the stacks of the goroutines of this daemon's toilers now,
+// but writes them to `writer` on another goroutine. So that a slow (or blocked) writer
+// does not hold up the daemon's goroutine.
+//
+// If the stacks from an earlier call are still being written, then these stacks are
+// dropped (rather than piling up behind them).
+func (daemon *internalGroupDaemon) dumpStacksInBackground(writer io.Writer) {
+	if !daemon.dumpMutex.TryLock() {
+		return
+	}
+
+	stacks, err := daemon.stacks()
+	if nil != err {
+		daemon.dumpMutex.Unlock()
+		return
+	}
+
+	go func() {
+		defer daemon.dumpMutex.Unlock()
+
+		writer.Write(stacks)
+	}()
+}
+
+
+// stacks returns the stacks of the goroutines of this daemon's toilers.
+func (daemon *internalGroupDaemon) stacks() ([]byte, error) {
+
+	var buffer bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buffer, 1); nil != err {
+		return nil, err
+	}
+
+	// With debug=1, the goroutine profile is made up of records separated by
+	// blank lines. Each record is a stack (shared by one or more goroutines)
+	// and the pprof labels of those goroutines.
+	//
+	// We only want the records with this daemon's group label.
+	needle := []byte(fmt.Sprintf("%q:%q", groupIDLabel, daemon.id))
+
+	var stacks bytes.Buffer
+	for _,record := range bytes.Split(buffer.Bytes(), []byte("\n\n")) {
+		if !bytes.Contains(record, needle) {
+			continue
+		}
+
+		stacks.Write(record)
+		stacks.WriteString("\n\n")
+	}
+
+	return stacks.Bytes(), nil
+}
+
+
+// DumpStacksOnSignal makes `group` write the stacks of its toilers' goroutines
+// to `writer` (see the DumpStacks method of Supervisor) each time the process receives
+// one of the signals. If no signals are given, then SIGQUIT is used. (On Plan 9, which
+// has no SIGQUIT, signals must be given. If none are, then nothing is done.)
+//
+// NOTE that the process will no longer do what it would have otherwise done when
+// it receives one of those signals. (For SIGQUIT, that is to dump all the goroutine
+// stacks and exit.)
+//
+// Calling the returned func undoes this.
+func DumpStacksOnSignal(group Supervisor, writer io.Writer, signals ...os.Signal) (stop func()) {
+	if 0 == len(signals) {
+		signals = defaultDumpStacksSignals
+	}
+	if 0 == len(signals) {
+		return func(){}
+	}
+
+	signalCh := make(chan os.Signal, 1)
+	doneCh   := make(chan struct{})
+
+	signal.Notify(signalCh, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-signalCh:
+				group.DumpStacks(writer)
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signalCh)
+		close(doneCh)
+	}
+}
@@ -0,0 +1,65 @@
+package toil
+
+
+import (
+	"testing"
+
+	"bytes"
+	"strings"
+	"time"
+)
+
+
+func dumpStacksBlockingToiler(blockCh chan struct{}) {
+	<-blockCh
+}
+
+
+func dumpStacksOtherBlockingToiler(blockCh chan struct{}) {
+	<-blockCh
+}
+
+
+func TestDumpStacks(t *testing.T) {
+
+	blockCh := make(chan struct{})
+
+	group := NewGroup()
+	group.Register( ToilerFunc(func(){ dumpStacksBlockingToiler(blockCh) }) )
+
+	otherGroup := NewGroup()
+	otherGroup.Register( ToilerFunc(func(){ dumpStacksOtherBlockingToiler(blockCh) }) )
+
+	doneCh := make(chan struct{}, 2)
+	go func() {
+		group.Toil()
+		doneCh <- struct{}{}
+	}()
+	go func() {
+		otherGroup.Toil()
+		doneCh <- struct{}{}
+	}()
+	defer func() {
+		close(blockCh)
+		<-doneCh
+		<-doneCh
+	}()
+
+	var buffer bytes.Buffer
+	ok := eventually(5*time.Second, func() bool {
+		buffer.Reset()
+		if err := group.DumpStacks(&buffer); nil != err {
+			t.Errorf("Did not expect an error, but actually got one: %v", err)
+		}
+		return strings.Contains(buffer.String(), "dumpStacksBlockingToiler")
+	})
+	if !ok {
+		t.Errorf("Expected the toiler's stack to be dumped, but actually got: %q", buffer.String())
+		return
+	}
+
+	if strings.Contains(buffer.String(), "dumpStacksOtherBlockingToiler") {
+		t.Errorf("Expected the other group's toiler's stack to not be dumped, but it was: %q", buffer.String())
+		return
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !plan9
+
+package toil
+
+
+import (
+	"os"
+	"syscall"
+)
+
+
+// defaultDumpStacksSignals are the signals that DumpStacksOnSignal uses if it is not
+// given any.
+var defaultDumpStacksSignals = []os.Signal{syscall.SIGQUIT}
@@ -0,0 +1,11 @@
+package toil
+
+
+import (
+	"os"
+)
+
+
+// defaultDumpStacksSignals is empty on Plan 9, which has no SIGQUIT. (See
+// DumpStacksOnSignal.)
+var defaultDumpStacksSignals []os.Signal
@@ -0,0 +1,23 @@
+package toil
+
+
+import (
+	"context"
+)
+
+
+// Elector is an interface that wraps the Campaign method.
+//
+// An Elector decides which one of (possibly) many replicas (i.e., instances, processes,
+// etc) is the leader. (See LeaderGate.)
+//
+// Campaign blocks until this replica becomes the leader, or `ctx` is done (in which
+// case it returns an error).
+//
+// When this replica becomes the leader, Campaign returns a lease context, that is
+// done when this replica stops being the leader (i.e., loses the leadership), and a
+// resign func, that gives up the leadership. The resign func must be called when the
+// leadership is no longer needed, even if it was lost.
+type Elector interface {
+	Campaign(ctx context.Context) (lease context.Context, resign context.CancelFunc, err error)
+}
@@ -0,0 +1,120 @@
+package toil
+
+
+import (
+	"fmt"
+	"time"
+)
+
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	EventStarted   EventKind = iota + 1 // A toiler started a run.
+	EventReturned                       // A toiler's run returned (gracefully).
+	EventPanicked                       // A toiler's run panic()ed.
+	EventTimedOut                       // A toiler's run timed out. (See Timeout.)
+	EventRestarted                      // A toiler was restarted. (See RestartPolicy and WatchdogRestart.)
+	EventPaused                         // A toiler was paused. (See Pause.)
+	EventResumed                        // A toiler was resumed. (See Resume.)
+)
+
+
+// String is part of the fmt.Stringer interface.
+func (kind EventKind) String() string {
+	switch kind {
+	case EventStarted:
+		return "started"
+	case EventReturned:
+		return "returned"
+	case EventPanicked:
+		return "panicked"
+	case EventTimedOut:
+		return "timed-out"
+	case EventRestarted:
+		return "restarted"
+	case EventPaused:
+		return "paused"
+	case EventResumed:
+		return "resumed"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(kind))
+	}
+}
+
+
+// Event is something that happened to one of a Group's toilers.
+//
+// See Events for how to get a Group's events.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+
+	// Group is the name of the Group. (See Name.)
+	Group string
+
+	// Toiler is the name of the toiler. (See Name.)
+	Toiler string
+
+	// Pool is the name of the pool the toiler is in. (See Pool.)
+	Pool string
+
+	// Run is the run number of the toiler's run the event is about. (The
+	// first run is run 1.) It is 0 for events not about a run.
+	Run int
+
+	// Value is the panic value, for EventPanicked events, and the *TimeoutError,
+	// for EventTimedOut events. Otherwise it is nil.
+	Value interface{}
+}
+
+
+// Events returns a GroupOption that makes the Group send its Events on `ch`.
+//
+// The Group does not block sending an Event. If `ch` is not ready to receive it
+// (for example, because its buffer is full), then the Event is dropped. So `ch`
+// should be buffered (enough).
+func Events(ch chan<- Event) GroupOption {
+	return eventsOption{
+		ch:ch,
+	}
+}
+
+
+type eventsOption struct {
+	ch chan<- Event
+}
+
+
+func (option eventsOption) applyGroupOption(config *groupConfig) {
+	config.eventChs = append(config.eventChs, option.ch)
+}
+
+
+// emit sends an event about a registered toiler to the daemon's event channels, and
+// writes it to the daemon's journals.
+func (daemon *internalGroupDaemon) emit(kind EventKind, registration *registeredToiler, run int, value interface{}) {
+	if 0 == len(daemon.config.eventChs) && 0 == len(daemon.config.journals) {
+		return
+	}
+
+	event := Event{
+		Kind:kind,
+		Time:daemon.config.clock.Now(),
+		Group:daemon.name(),
+		Toiler:registration.name(),
+		Pool:registration.pool.name,
+		Run:run,
+		Value:value,
+	}
+
+	for _,ch := range daemon.config.eventChs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+
+	daemon.journal(event)
+}
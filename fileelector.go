@@ -0,0 +1,145 @@
+package toil
+
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+
+// ErrFileLockUnsupported is returned by the Campaign method of a file elector (see
+// NewFileElector) on a platform where flock is not supported.
+var ErrFileLockUnsupported = errors.New("toil: file lock unsupported")
+
+
+// defaultFileElectorInterval is how often a file elector (by default) tries to lock
+// its file, and checks that it has not lost it.
+const defaultFileElectorInterval = 100 * time.Millisecond
+
+
+// NewFileElector returns an Elector that uses an (advisory) file lock (i.e., flock)
+// on the file at `path` to decide which replica is the leader. Whichever replica holds
+// the lock is the leader.
+//
+// The file is created if it does not exist. For this to work across machines, the
+// file must be on a (shared) file system that supports flock.
+//
+// Every `interval` (or 100 milliseconds, if `interval` is not positive), a replica that
+// is not the leader tries to lock the file, and a replica that is the leader checks
+// that the file still exists, and is the one it locked. (If it is not, then the
+// leadership is lost.)
+//
+// NewFileElector is useful locally, and in tests.
+//
+// NOTE that flock is only supported on Linux, macOS (and iOS), and the BSDs. On
+// other platforms (for example, Windows) Campaign always returns ErrFileLockUnsupported.
+func NewFileElector(path string, interval time.Duration) Elector {
+	if interval <= 0 {
+		interval = defaultFileElectorInterval
+	}
+
+	elector := fileElector{
+		path:path,
+		interval:interval,
+	}
+
+	return &elector
+}
+
+
+type fileElector struct {
+	path     string
+	interval time.Duration
+}
+
+
+// Campaign is part of the Elector interface.
+func (elector *fileElector) Campaign(ctx context.Context) (context.Context, context.CancelFunc, error) {
+
+	ticker := time.NewTicker(elector.interval)
+	defer ticker.Stop()
+
+	for {
+		file, err := elector.lock()
+		if nil != err {
+			return nil, nil, err
+		}
+		if nil != file {
+			return elector.lease(ctx, file)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+
+// lock tries to lock the file. It returns a nil file (and a nil error) if the file
+// is locked by someone else.
+func (elector *fileElector) lock() (*os.File, error) {
+	file, err := os.OpenFile(elector.path, os.O_RDWR|os.O_CREATE, 0644)
+	if nil != err {
+		return nil, err
+	}
+
+	locked, err := lockFile(file)
+	if nil != err {
+		file.Close()
+		return nil, err
+	}
+	if !locked {
+		file.Close()
+		return nil, nil
+	}
+
+	return file, nil
+}
+
+
+// lease returns a lease context for the locked file, that is done when the file no
+// longer exists (or has been replaced), and a resign func that unlocks it.
+func (elector *fileElector) lease(ctx context.Context, file *os.File) (context.Context, context.CancelFunc, error) {
+
+	lease, cancel := context.WithCancel(ctx)
+
+	var resignOnce sync.Once
+	resign := func() {
+		resignOnce.Do(func(){
+			cancel()
+			unlockFile(file)
+			file.Close()
+		})
+	}
+
+	lockedInfo, err := file.Stat()
+	if nil != err {
+		resign()
+		return nil, nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(elector.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				info, err := os.Stat(elector.path)
+				if nil != err || !os.SameFile(lockedInfo, info) {
+					cancel()
+					return
+				}
+			case <-lease.Done():
+				return
+			}
+		}
+	}()
+
+	return lease, resign, nil
+}
@@ -0,0 +1,24 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package toil
+
+
+import (
+	"testing"
+
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+
+func TestFileElectorUnsupported(t *testing.T) {
+
+	elector := NewFileElector(filepath.Join(t.TempDir(), "leader.lock"), 5*time.Millisecond)
+
+	_, _, err := elector.Campaign(context.Background())
+	if expected, actual := ErrFileLockUnsupported, err; !errors.Is(actual, expected) {
+		t.Errorf("Expected the error to be %v, but actually was: (%T) %v", expected, actual, actual)
+	}
+}
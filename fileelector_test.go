@@ -0,0 +1,56 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package toil
+
+
+import (
+	"testing"
+
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+
+func TestFileElector(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "leader.lock")
+
+	elector1 := NewFileElector(path, 5*time.Millisecond)
+	elector2 := NewFileElector(path, 5*time.Millisecond)
+
+	lease1, resign1, err := elector1.Campaign(context.Background())
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := elector2.Campaign(ctx); nil == err {
+		t.Fatalf("Expected the second elector to not become the leader while the first one is, but it did.")
+	}
+
+	resign1()
+
+	if nil == lease1.Err() {
+		t.Errorf("Expected the lease to be done after resigning, but it was not.")
+	}
+
+	lease2, resign2, err := elector2.Campaign(context.Background())
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer resign2()
+
+	if err := os.Remove(path); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	select {
+	case <-lease2.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the leadership to be lost after the file was removed, but it was not.")
+	}
+}
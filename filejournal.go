@@ -0,0 +1,110 @@
+package toil
+
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+
+// FileJournal is a Journal that appends each Event, as a line of JSON, to a file.
+// (See NewFileJournal.)
+type FileJournal struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+
+// NewFileJournal returns a FileJournal that appends to the file at `path`. The file is
+// created if it does not exist.
+//
+// Events are written to the file without buffering, so that they are not lost if the
+// process crashes. (They can still be lost if the operating system crashes.)
+//
+// If the file ends with an incomplete line (for example, because the process crashed
+// while writing it), then that line is ended, so that the Events written after it are
+// not written onto it.
+//
+// Example:
+//
+//	journal, err := toil.NewFileJournal("/var/log/awesome/toil.jsonl")
+//	if nil != err {
+//		return err
+//	}
+//	defer journal.Close()
+//	
+//	var (
+//		ToilerGroup = toil.NewGroup(toil.WithJournal(journal))
+//	)
+func NewFileJournal(path string) (*FileJournal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if nil != err {
+		return nil, err
+	}
+
+	if err := endIncompleteLine(file); nil != err {
+		file.Close()
+		return nil, err
+	}
+
+	journal := FileJournal{
+		file:file,
+	}
+
+	return &journal, nil
+}
+
+
+// endIncompleteLine writes a newline to the end of the file, if the file does not
+// already end with one (and is not empty).
+func endIncompleteLine(file *os.File) error {
+	info, err := file.Stat()
+	if nil != err {
+		return err
+	}
+
+	size := info.Size()
+	if 0 == size {
+		return nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); nil != err {
+		return err
+	}
+
+	if '\n' == last[0] {
+		return nil
+	}
+
+	_, err = file.Write([]byte{'\n'})
+	return err
+}
+
+
+// WriteEvent is part of the Journal interface.
+func (journal *FileJournal) WriteEvent(event Event) error {
+	line, err := json.Marshal(newJournalRecord(event))
+	if nil != err {
+		return err
+	}
+	line = append(line, '\n')
+
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	// NOTE that this is a single write, so that (with O_APPEND) lines from different
+	// processes writing to the same file do not get interleaved.
+	_, err = journal.file.Write(line)
+	return err
+}
+
+
+// Close closes the file.
+func (journal *FileJournal) Close() error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	return journal.file.Close()
+}
@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package toil
+
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+
+// lockFile tries to lock `file` (with flock), without blocking. It returns false (and
+// a nil error) if the file is locked by someone else.
+func lockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	if nil != err {
+		return false, err
+	}
+
+	return true, nil
+}
+
+
+// unlockFile unlocks `file`.
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
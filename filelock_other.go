@@ -0,0 +1,21 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package toil
+
+
+import (
+	"os"
+)
+
+
+// lockFile always returns ErrFileLockUnsupported, as flock is not supported on this
+// platform.
+func lockFile(file *os.File) (bool, error) {
+	return false, ErrFileLockUnsupported
+}
+
+
+// unlockFile does nothing, as a file can never be locked on this platform.
+func unlockFile(file *os.File) error {
+	return nil
+}
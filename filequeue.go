@@ -0,0 +1,490 @@
+package toil
+
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+
+// FileQueue is a Queue (embedded in the process) that keeps its tasks in an append-only
+// log file. (See NewFileQueue.)
+//
+// Each change to the queue (a task being enqueued, delivered, acknowledged, or
+// dead-lettered) is appended to the file, as a line of JSON, and synced to disk, before
+// the method that made the change returns. So that the tasks survive the process
+// crashing (and restarting).
+//
+// When the file is opened again, tasks that were delivered, but not acknowledged, are
+// delivered again. (With their number of attempts so far remembered. So that a task that
+// keeps crashing the process is dead-lettered once it has been delivered too many times.)
+//
+// NOTE that the file only grows. (Tasks that were acknowledged are not removed from it.)
+type FileQueue struct {
+	config queueConfig
+
+	mutex       sync.Mutex
+	file        *os.File
+	closed      bool
+	nextID      uint64
+	tasks       []*queuedTask // NOTE that these are in the order they were enqueued.
+	deadLetters []Task
+	changedCh   chan struct{} // NOTE that this is closed (and replaced) whenever a task might have become deliverable.
+}
+
+
+// queuedTask is a task in a FileQueue (that has not been acknowledged or dead-lettered).
+type queuedTask struct {
+	task     Task
+	inFlight bool      // Whether it was delivered, and is waiting to be acknowledged.
+	deadline time.Time // When it is delivered again, if it is in flight.
+	receipt  string    // The receipt of its current delivery, if it is in flight.
+}
+
+
+// queueRecord is a line in the file of a FileQueue.
+type queueRecord struct {
+	Op      string `json:"op"`
+	ID      string `json:"id"`
+	Payload []byte `json:"payload,omitempty"`
+}
+
+
+const (
+	queueOpEnqueue = "enqueue"
+	queueOpDeliver = "deliver"
+	queueOpAck     = "ack"
+	queueOpDead    = "dead"
+)
+
+
+// NewFileQueue returns a FileQueue that keeps its tasks in the file at `path`. The file
+// is created if it does not exist. If it does exist, then the tasks in it are loaded.
+//
+// Example:
+//
+//	queue, err := toil.NewFileQueue("/var/lib/awesome/jobs.jsonl", toil.MaxAttempts(3), toil.VisibilityTimeout(time.Minute))
+//	if nil != err {
+//		return err
+//	}
+//	defer queue.Close()
+func NewFileQueue(path string, options ...QueueOption) (*FileQueue, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if nil != err {
+		return nil, err
+	}
+
+	queue := FileQueue{
+		config:newQueueConfig(options...),
+		file:file,
+		changedCh:make(chan struct{}),
+	}
+
+	if err := queue.load(file); nil != err {
+		file.Close()
+		return nil, err
+	}
+
+	return &queue, nil
+}
+
+
+// load loads the tasks from the file, by replaying the records in it.
+//
+// An incomplete last line (for example, because the process crashed while writing it)
+// is ignored, and truncated from the file, so that the records appended after it are
+// not appended onto it. Any other line that is not valid JSON is also ignored. (Like
+// with ReadJournal.)
+func (queue *FileQueue) load(file *os.File) error {
+	byID := map[string]*queuedTask{}
+
+	bufferedReader := bufio.NewReader(file)
+
+	var offset int64
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := bufferedReader.ReadBytes('\n')
+		if io.EOF == err {
+			// NOTE that a last line without a newline is incomplete.
+			if 0 < len(line) {
+				if err := file.Truncate(offset); nil != err {
+					return err
+				}
+			}
+			break
+		}
+		if nil != err {
+			return err
+		}
+		offset += int64(len(line))
+
+		var record queueRecord
+		if err := json.Unmarshal(line, &record); nil != err {
+			continue
+		}
+
+		switch record.Op {
+		case queueOpEnqueue:
+			queued := &queuedTask{
+				task:Task{ID:record.ID, Payload:record.Payload},
+			}
+			byID[record.ID] = queued
+			queue.tasks = append(queue.tasks, queued)
+
+			if n, err := strconv.ParseUint(record.ID, 10, 64); nil == err && queue.nextID <= n {
+				queue.nextID = n + 1
+			}
+		case queueOpDeliver:
+			if queued, ok := byID[record.ID]; ok {
+				queued.task.Attempts++
+			}
+		case queueOpAck:
+			delete(byID, record.ID)
+		case queueOpDead:
+			if queued, ok := byID[record.ID]; ok {
+				queue.deadLetters = append(queue.deadLetters, queued.task)
+			}
+			delete(byID, record.ID)
+		default:
+			return fmt.Errorf("toil: queue line %d: unknown op %q", lineNumber, record.Op)
+		}
+	}
+
+	var tasks []*queuedTask
+	for _,queued := range queue.tasks {
+		if _, ok := byID[queued.task.ID]; ok {
+			tasks = append(tasks, queued)
+		}
+	}
+	queue.tasks = tasks
+
+	return nil
+}
+
+
+// write appends a record to the file, and syncs it to disk.
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) write(record queueRecord) error {
+	line, err := json.Marshal(record)
+	if nil != err {
+		return err
+	}
+	line = append(line, '\n')
+
+	if _, err := queue.file.Write(line); nil != err {
+		return err
+	}
+
+	return queue.file.Sync()
+}
+
+
+// changed wakes up anything waiting for a task to become deliverable.
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) changed() {
+	close(queue.changedCh)
+	queue.changedCh = make(chan struct{})
+}
+
+
+// Enqueue is part of the Queue interface.
+func (queue *FileQueue) Enqueue(payload []byte) (string, error) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	if queue.closed {
+		return "", ErrQueueClosed
+	}
+
+	id := strconv.FormatUint(queue.nextID, 10)
+
+	if err := queue.write(queueRecord{Op:queueOpEnqueue, ID:id, Payload:payload}); nil != err {
+		return "", err
+	}
+
+	queue.nextID++
+	queue.tasks = append(queue.tasks, &queuedTask{
+		task:Task{ID:id, Payload:append([]byte(nil), payload...)},
+	})
+	queue.changed()
+
+	return id, nil
+}
+
+
+// Dequeue is part of the Queue interface.
+func (queue *FileQueue) Dequeue(ctx context.Context) (Task, error) {
+	for {
+		queue.mutex.Lock()
+		task, found, err := queue.deliver()
+		nextDeadline := queue.nextDeadline()
+		changedCh    := queue.changedCh
+		now          := queue.config.clock.Now()
+		queue.mutex.Unlock()
+
+		if nil != err || found {
+			return task, err
+		}
+
+		// Wait until a task is enqueued (or nacked), or the visibility timeout of
+		// an in-flight task runs out.
+		var timerCh <-chan time.Time
+		var stopTimer func() bool
+		if !nextDeadline.IsZero() {
+			timerCh, stopTimer = queue.config.clock.NewTimer(nextDeadline.Sub(now))
+		}
+
+		select {
+		case <-changedCh:
+		case <-timerCh:
+		case <-ctx.Done():
+			if nil != stopTimer {
+				stopTimer()
+			}
+			return Task{}, ctx.Err()
+		}
+
+		if nil != stopTimer {
+			stopTimer()
+		}
+	}
+}
+
+
+// deliver delivers the next deliverable task, if there is one.
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) deliver() (Task, bool, error) {
+	if queue.closed {
+		return Task{}, false, ErrQueueClosed
+	}
+
+	now := queue.config.clock.Now()
+
+	if err := queue.expire(now); nil != err {
+		return Task{}, false, err
+	}
+
+	for _,queued := range append([]*queuedTask(nil), queue.tasks...) {
+		if queued.inFlight {
+			continue
+		}
+
+		// A task that has already been delivered too many times (without being
+		// negatively acknowledged, such as because doing it crashed the process)
+		// is dead-lettered, rather than delivered again.
+		if queue.exhausted(queued) {
+			if err := queue.deadLetter(queued); nil != err {
+				return Task{}, false, err
+			}
+			continue
+		}
+
+		if err := queue.write(queueRecord{Op:queueOpDeliver, ID:queued.task.ID}); nil != err {
+			return Task{}, false, err
+		}
+
+		queued.task.Attempts++
+		queued.inFlight = true
+		queued.deadline = now.Add(queue.config.visibilityTimeout)
+
+		// NOTE that the number of attempts is different for each delivery (even after
+		// the file is opened again), so the receipt is too.
+		queued.receipt = queued.task.ID + "#" + strconv.Itoa(queued.task.Attempts)
+
+		task := queued.task
+		task.Payload = append([]byte(nil), task.Payload...)
+		task.Receipt = queued.receipt
+
+		return task, true, nil
+	}
+
+	return Task{}, false, nil
+}
+
+
+// expire makes the in-flight tasks whose visibility timeout has run out deliverable
+// again (or dead-letters them).
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) expire(now time.Time) error {
+	for _,queued := range queue.tasks {
+		if !queued.inFlight || now.Before(queued.deadline) {
+			continue
+		}
+
+		if err := queue.release(queued); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+
+// nextDeadline returns the earliest deadline of the in-flight tasks. (Or the zero time.)
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) nextDeadline() time.Time {
+	var next time.Time
+	for _,queued := range queue.tasks {
+		if queued.inFlight && (next.IsZero() || queued.deadline.Before(next)) {
+			next = queued.deadline
+		}
+	}
+
+	return next
+}
+
+
+// release makes an in-flight task deliverable again. Or, if it has been delivered too
+// many times already, dead-letters it.
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) release(queued *queuedTask) error {
+	if queue.exhausted(queued) {
+		return queue.deadLetter(queued)
+	}
+
+	queued.inFlight = false
+	queued.receipt  = ""
+	queue.changed()
+
+	return nil
+}
+
+
+// exhausted returns whether a task has been delivered too many times already.
+func (queue *FileQueue) exhausted(queued *queuedTask) bool {
+	return 0 < queue.config.maxAttempts && queue.config.maxAttempts <= queued.task.Attempts
+}
+
+
+// deadLetter dead-letters a task.
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) deadLetter(queued *queuedTask) error {
+	if err := queue.write(queueRecord{Op:queueOpDead, ID:queued.task.ID}); nil != err {
+		return err
+	}
+
+	queue.remove(queued)
+	queue.deadLetters = append(queue.deadLetters, queued.task)
+
+	return nil
+}
+
+
+// remove removes a task.
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) remove(queued *queuedTask) {
+	for i,q := range queue.tasks {
+		if q == queued {
+			queue.tasks = append(queue.tasks[:i], queue.tasks[i+1:]...)
+			return
+		}
+	}
+}
+
+
+// find returns the in-flight task whose current delivery has the receipt `receipt`.
+// (Or nil.)
+//
+// NOTE that the mutex must be locked when this is called.
+func (queue *FileQueue) find(receipt string) *queuedTask {
+	for _,queued := range queue.tasks {
+		if queued.inFlight && receipt == queued.receipt {
+			return queued
+		}
+	}
+
+	return nil
+}
+
+
+// Ack is part of the Queue interface.
+func (queue *FileQueue) Ack(receipt string) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	if queue.closed {
+		return ErrQueueClosed
+	}
+
+	queued := queue.find(receipt)
+	if nil == queued {
+		return ErrStaleReceipt
+	}
+
+	if err := queue.write(queueRecord{Op:queueOpAck, ID:queued.task.ID}); nil != err {
+		return err
+	}
+
+	queue.remove(queued)
+
+	return nil
+}
+
+
+// Nack is part of the Queue interface.
+func (queue *FileQueue) Nack(receipt string) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	if queue.closed {
+		return ErrQueueClosed
+	}
+
+	queued := queue.find(receipt)
+	if nil == queued {
+		return ErrStaleReceipt
+	}
+
+	return queue.release(queued)
+}
+
+
+// Len returns the number of tasks in the queue that have not been acknowledged (or
+// dead-lettered). (Including the in-flight ones.)
+func (queue *FileQueue) Len() int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	return len(queue.tasks)
+}
+
+
+// DeadLetters returns (a copy of) the tasks that were dead-lettered, in the order they
+// were dead-lettered.
+func (queue *FileQueue) DeadLetters() []Task {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	return append([]Task(nil), queue.deadLetters...)
+}
+
+
+// Close closes the file. Anything blocked in Dequeue returns ErrQueueClosed.
+func (queue *FileQueue) Close() error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	if queue.closed {
+		return ErrQueueClosed
+	}
+
+	queue.closed = true
+	queue.changed()
+
+	return queue.file.Close()
+}
+
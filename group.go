@@ -1,7 +1,25 @@
 package toil
 
 
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+
+// osExit is what the ExitProcess PanicPolicy calls to exit the process.
+//
+// It is a variable so that it can be replaced in tests.
+var osExit = os.Exit
+
+
 // Group is an interface that wraps the Len, Register and Toil methods.
+//
+// (The Group that NewGroup returns is also a Supervisor, which has more methods. Such
+// as Stop, Pause, Resume and Status.)
 type Group interface {
 
 	// Len returns the number of toilers registered with this Group.
@@ -12,25 +30,97 @@ type Group interface {
 
 	// Toil makes all the toilers registered with this Group toil (i.e., do work),
 	// by calling each of the registered toilers' Toil methods.
+	//
+	// What Toil does when one of the toilers' Toil methods panic()s is decided
+	// by the Group's PanicPolicy.
 	Toil()
 }
 
 
+// Supervisor is an interface that wraps the Len, Register, RegisterWith, Toil, Stop, Pause,
+// Resume, Reload, Err, Panics, Status and DumpStacks methods.
+//
+// I.e., a Supervisor is a Group that can also be stopped, paused, resumed and reloaded,
+// and that reports on its toilers. (NewGroup returns a Supervisor.)
+type Supervisor interface {
+	Group
+
+	// RegisterWith registers a toiler with this Group, with RegisterOption(s)
+	// that configure how this Group makes that toiler toil. (Such as a Timeout.)
+	//
+	// RegisterWith returns a Registration, which can be used to pause and resume
+	// the toiler, and get its status.
+	RegisterWith(Toiler, ...RegisterOption) Registration
+
+	// Stop asks all the toilers registered with this Group to stop toiling, by
+	// calling the Stop method of each of the registered toilers that is also
+	// a Stopper.
+	//
+	// Stop does not wait for the toilers to stop toiling. (The Toil method will
+	// return once they have all stopped toiling.)
+	//
+	// If Stop is called before Toil, then Toil will not make any of the toilers toil.
+	Stop()
+
+	// Pause pauses all the toilers registered with this Group (including any toilers
+	// registered while it is paused). (See the Pause method of Registration.)
+	Pause()
+
+	// Resume resumes all the toilers registered with this Group. (See the Resume
+	// method of Registration.)
+	Resume()
+
+	// Reload makes all the toilers registered with this Group reload (their configuration),
+	// and returns the outcome for each of them. (See Reloader.)
+	Reload(context.Context) []ReloadOutcome
+
+	// Err returns the error that made this Group stop toiling, if any.
+	//
+	// With the StopGroup PanicPolicy, this is the *PanicError of the first toiler
+	// that panic()ed.
+	Err() error
+
+	// Panics returns the *PanicError for each time one of the toilers registered
+	// with this Group panic()ed, in the order the Group received them.
+	Panics() []*PanicError
+
+	// Status returns the status of each of the toilers registered with this Group,
+	// in the order they were registered.
+	Status() []ToilerStatus
+
+	// DumpStacks writes the stacks of the goroutines that the toilers registered
+	// with this Group are toiling in to the writer. (And only those goroutines.)
+	//
+	// This can be useful to find out which toiler is keeping Toil from returning.
+	DumpStacks(io.Writer) error
+}
+
+
 type internalGroup struct {
 	daemon *internalGroupDaemon
-	panicCh chan interface{}
+	panicCh chan *PanicError
+	config groupConfig
+
+	mutex sync.Mutex
+	err    error
+	panics []*PanicError
 }
 
 
-// NewGroup returns an initialized Group.
-func NewGroup() Group {
-	panicCh := make(chan interface{})
+// NewGroup returns an initialized Group. (Which is also a Supervisor.)
+//
+// NewGroup can be given GroupOption(s) to configure the Group, such as a PanicPolicy.
+func NewGroup(options ...GroupOption) Supervisor {
+	panicCh := make(chan *PanicError)
 
-	groupDaemon := newGroupDaemon(panicCh)
+	config := newGroupConfig(options...)
+
+	groupDaemon := newGroupDaemon(panicCh, config)
 
 	group := internalGroup{
 		daemon:groupDaemon,
 		panicCh:panicCh,
+		config:config,
 	}
 
 	return &group
@@ -38,13 +128,21 @@ func NewGroup() Group {
 
 
 func (group *internalGroup) Len() int {
-	lengthReturnCh := make(chan int)
+	lengthReturnCh := make(chan int, 1) // NOTE that this is buffered, so that the request can also be served by the exited method.
 	defer close(lengthReturnCh)
 
-	group.daemon.LengthCh() <- struct{returnCh chan int}{
+	lengthRequest := struct{returnCh chan int}{
 		returnCh:lengthReturnCh,
 	}
 
+	select {
+	case group.daemon.LengthCh() <- lengthRequest:
+	case <-group.daemon.ExitedCh():
+		group.daemon.exited(func(){
+			group.daemon.serveLength(lengthRequest)
+		})
+	}
+
 	length := <-lengthReturnCh
 
 	return length
@@ -52,48 +150,210 @@ func (group *internalGroup) Len() int {
 
 
 func (group *internalGroup) Register(toiler Toiler) {
-	doneCh := make(chan struct{})
+	group.RegisterWith(toiler)
+}
+
+
+func (group *internalGroup) RegisterWith(toiler Toiler, options ...RegisterOption) Registration {
+	doneCh := make(chan struct{}, 1) // NOTE that this is buffered, so that the request can also be served by the exited method.
 	defer close(doneCh)
 
-	group.daemon.RegisterCh() <- struct{doneCh chan struct{}; toiler Toiler}{
-		doneCh:doneCh,
+	registration := registeredToiler{
 		toiler:toiler,
+		config:newRegisterConfig(options...),
+	}
+
+	registrationRequest := struct{doneCh chan struct{}; registration *registeredToiler}{
+		doneCh:doneCh,
+		registration:&registration,
+	}
+
+	select {
+	case group.daemon.RegisterCh() <- registrationRequest:
+	case <-group.daemon.ExitedCh():
+		group.daemon.exited(func(){
+			group.daemon.serveRegister(registrationRequest)
+		})
 	}
 
 	<-doneCh // NOTE that we are waiting on this before we call
 	         // the Wait() method below to avoid a race condition.
+
+	return internalRegistration{
+		daemon:group.daemon,
+		registration:&registration,
+	}
 }
 
 
 func (group *internalGroup) Toil() {
 	// By sending on this channel, we make all the toilers
 	// registered in this group toil.
-	doneCh := make(chan struct{})
+	doneCh := make(chan struct{}, 1) // NOTE that this is buffered, so that the request can also be served by the exited method.
 
-	group.daemon.ToilCh() <- struct{doneCh chan struct{}}{
+	toilRequest := struct{doneCh chan struct{}}{
 		doneCh:doneCh,
 	}
 
+	select {
+	case group.daemon.ToilCh() <- toilRequest:
+	case <-group.daemon.ExitedCh():
+		group.daemon.exited(func(){
+			group.daemon.serveToil(toilRequest)
+		})
+	}
+
 	<-doneCh // NOTE that we are waiting on this before we call
 	         // the Wait() method below to avoid a race condition.
 
 
-	// Block while any toiler in this group is still toiling and
-	// none of them have panic()ed.
+	// Block while any toiler in this group is still toiling.
 	//
-	// If any panic() then this panic()s.
-	waitForThem := func() (<-chan struct{}) {
-		ch := make(chan struct{})
-		go func() {
-			group.daemon.Waiter().Wait()
-			ch <- struct{}{}
-		}()
-		return ch
+	// If any panic() then what happens is decided by the panic policy.
+	waitCh := make(chan struct{})
+	go func() {
+		group.daemon.Waiter().Wait()
+		close(waitCh)
+	}()
+
+	for {
+		select {
+		case panicError := <-group.panicCh:
+			group.panicked(panicError)
+		case <-waitCh:
+			return
+		}
 	}
+}
+
+
+// panicked applies the group's panic policy to a toiler having panic()ed.
+func (group *internalGroup) panicked(panicError *PanicError) {
+
+	group.mutex.Lock()
+	group.panics = append(group.panics, panicError)
+	group.mutex.Unlock()
+
+	policy := group.config.panicPolicy
+
+	switch policy.kind {
+	case isolatePanicPolicyKind:
+		group.recovered(panicError)
+
+		// Nothing else to do. The rest of the toilers keep toiling.
+	case stopGroupPanicPolicyKind:
+		group.recovered(panicError)
 
+		group.mutex.Lock()
+		if nil == group.err {
+			group.err = panicError
+		}
+		group.mutex.Unlock()
+
+		group.Stop()
+	case exitProcessPanicPolicyKind:
+		log.Printf("%v\n%s", panicError, panicError.Stack)
+		osExit(policy.exitCode)
+	default:
+		panic(panicError.Value)
+	}
+}
+
+
+// recovered is called when the group's panic policy decided to recover from a
+// toiler's panic, rather than propagate it.
+func (group *internalGroup) recovered(panicError *PanicError) {
+
+	group.daemon.recovered(panicError.Toiler, panicError.Value)
+}
+
+
+func (group *internalGroup) Stop() {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	// NOTE that once the daemon's goroutine has exited, the daemon has already been
+	// stopped. So, there is nothing to do.
 	select {
-	case panicValue := <-group.panicCh:
-		panic(panicValue)
-	case <-waitForThem():
+	case group.daemon.StopCh() <- struct{doneCh chan struct{}}{doneCh:doneCh}:
+		<-doneCh
+	case <-group.daemon.ExitedCh():
+	}
+}
+
+
+func (group *internalGroup) Pause() {
+	group.pause(true)
+}
+
+
+func (group *internalGroup) Resume() {
+	group.pause(false)
+}
+
+
+func (group *internalGroup) pause(pause bool) {
+	doneCh := make(chan struct{}, 1) // NOTE that this is buffered, so that the request can also be served by the exited method.
+	defer close(doneCh)
+
+	pauseRequest := struct{doneCh chan struct{}; pause bool}{
+		doneCh:doneCh,
+		pause:pause,
 	}
+
+	select {
+	case group.daemon.PauseCh() <- pauseRequest:
+	case <-group.daemon.ExitedCh():
+		group.daemon.exited(func(){
+			group.daemon.servePause(pauseRequest)
+		})
+	}
+
+	<-doneCh
+}
+
+
+func (group *internalGroup) Err() error {
+	group.mutex.Lock()
+	defer group.mutex.Unlock()
+
+	return group.err
+}
+
+
+func (group *internalGroup) Panics() []*PanicError {
+	group.mutex.Lock()
+	defer group.mutex.Unlock()
+
+	panics := make([]*PanicError, len(group.panics))
+	copy(panics, group.panics)
+
+	return panics
+}
+
+
+func (group *internalGroup) Status() []ToilerStatus {
+	statusReturnCh := make(chan []ToilerStatus, 1) // NOTE that this is buffered, so that the request can also be served by the exited method.
+	defer close(statusReturnCh)
+
+	statusRequest := struct{returnCh chan []ToilerStatus}{
+		returnCh:statusReturnCh,
+	}
+
+	select {
+	case group.daemon.StatusCh() <- statusRequest:
+	case <-group.daemon.ExitedCh():
+		group.daemon.exited(func(){
+			group.daemon.serveStatus(statusRequest)
+		})
+	}
+
+	statuses := <-statusReturnCh
+
+	return statuses
+}
+
+
+func (group *internalGroup) DumpStacks(writer io.Writer) error {
+	return group.daemon.dumpStacks(writer)
 }
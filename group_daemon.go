@@ -2,33 +2,88 @@ package toil
 
 
 import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"runtime/pprof"
 	"sync"
+	"time"
 )
 
 
 type internalGroupDaemon struct {
-	waitGroup       sync.WaitGroup
-	panicCh    chan<- interface{}
+	id              string
+	runCounter      runCounter
+	config          groupConfig
+	startLimiter   *startLimiter
+	pools           map[string]*toilerPool
+	ctx             context.Context
+	cancel          context.CancelFunc
+	stoppedCh  chan struct{}
+	panicCh    chan<- *PanicError
 	lengthCh   chan struct{returnCh chan int}
+	pauseCh    chan struct{doneCh   chan struct{}; pause bool}
 	pingCh     chan struct{doneCh   chan struct{}}
-	registerCh chan struct{doneCh   chan struct{}; toiler Toiler}
+	registerCh chan struct{doneCh   chan struct{}; registration *registeredToiler}
+	registrationsCh chan struct{returnCh chan []*registeredToiler}
+	statusCh   chan struct{returnCh chan []ToilerStatus}
+	stopCh     chan struct{doneCh   chan struct{}}
 	toilCh     chan struct{doneCh   chan struct{}}
+	exitedCh   chan struct{}
+	dumpMutex  sync.Mutex // NOTE that this is locked while the stacks are being written. (See dumpStacksInBackground.)
+
+	// NOTE that these are only used by the daemon's goroutine. Or, once the daemon's
+	// goroutine has exited (see exitedCh), with exitedMutex locked.
+	exitedMutex   sync.Mutex
+	registrations []*registeredToiler
+	toiling       bool
+	paused        bool
 }
 
 
-func newGroupDaemon(panicCh chan<- interface{}) *internalGroupDaemon {
+func newGroupDaemon(panicCh chan<- *PanicError, config groupConfig) *internalGroupDaemon {
 
 	lengthCh   := make(chan struct{returnCh chan int})
+	pauseCh    := make(chan struct{doneCh   chan struct{}; pause bool})
 	pingCh     := make(chan struct{doneCh   chan struct{}})
-	registerCh := make(chan struct{doneCh   chan struct{}; toiler Toiler})
+	registerCh := make(chan struct{doneCh   chan struct{}; registration *registeredToiler})
+	registrationsCh := make(chan struct{returnCh chan []*registeredToiler})
+	statusCh   := make(chan struct{returnCh chan []ToilerStatus})
+	stopCh     := make(chan struct{doneCh   chan struct{}})
 	toilCh     := make(chan struct{doneCh   chan struct{}})
 
+	// This context is the parent of the contexts given to the toilers' ToilContext
+	// methods. It gets cancelled when the daemon is stopped.
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stoppedCh := make(chan struct{})
+
 	daemon := internalGroupDaemon{
+		id:nextGroupID(),
+		config:config,
+		startLimiter:newStartLimiter(config.startRate, config.clock),
+		pools:map[string]*toilerPool{},
+		ctx:ctx,
+		cancel:cancel,
+		stoppedCh:stoppedCh,
 		panicCh:panicCh,
 		lengthCh:lengthCh,
+		pauseCh:pauseCh,
 		pingCh:pingCh,
 		toilCh:toilCh,
 		registerCh:registerCh,
+		registrationsCh:registrationsCh,
+		statusCh:statusCh,
+		stopCh:stopCh,
+		exitedCh:make(chan struct{}),
+		registrations:make([]*registeredToiler, 0, 8),
+	}
+
+	// The default pool (i.e., the pool toilers not registered into a pool are in),
+	// gets its configuration from the group's configuration.
+	daemon.pools[""] = newToilerPool("", config, stoppedCh)
+	for name,poolConfig := range config.pools {
+		daemon.pools[name] = newToilerPool(name, poolConfig, stoppedCh)
 	}
 
 	go daemon.animate()
@@ -38,11 +93,15 @@ func newGroupDaemon(panicCh chan<- interface{}) *internalGroupDaemon {
 
 
 func (daemon *internalGroupDaemon) Waiter() waiter {
-	return &daemon.waitGroup
+	return &daemon.runCounter
 }
 
 
 
+func (daemon *internalGroupDaemon) PauseCh() chan<- struct{doneCh chan struct{}; pause bool} {
+	return daemon.pauseCh
+}
+
 func (daemon *internalGroupDaemon) PingCh() chan<- struct{doneCh chan struct{}} {
 	return daemon.pingCh
 }
@@ -51,79 +110,445 @@ func (daemon *internalGroupDaemon) LengthCh() chan<- struct{returnCh chan int} {
 	return daemon.lengthCh
 }
 
-func (daemon *internalGroupDaemon) RegisterCh() chan<- struct{doneCh chan struct{}; toiler Toiler} {
+func (daemon *internalGroupDaemon) RegisterCh() chan<- struct{doneCh chan struct{}; registration *registeredToiler} {
 	return daemon.registerCh
 }
 
+func (daemon *internalGroupDaemon) RegistrationsCh() chan<- struct{returnCh chan []*registeredToiler} {
+	return daemon.registrationsCh
+}
+
+func (daemon *internalGroupDaemon) StatusCh() chan<- struct{returnCh chan []ToilerStatus} {
+	return daemon.statusCh
+}
+
+func (daemon *internalGroupDaemon) StopCh() chan<- struct{doneCh chan struct{}} {
+	return daemon.stopCh
+}
+
 func (daemon *internalGroupDaemon) ToilCh() chan<- struct{doneCh chan struct{}} {
 	return daemon.toilCh
 }
 
+// ExitedCh returns a channel that is closed once the daemon's goroutine has exited.
+// (After which, requests must be served with the exited method, rather than sent on
+// the channels above.)
+func (daemon *internalGroupDaemon) ExitedCh() <-chan struct{} {
+	return daemon.exitedCh
+}
+
 
 
+// animate is the daemon's goroutine. It serves the requests sent on the daemon's
+// channels.
+//
+// Once the daemon is done (i.e., it has been stopped, and all its toilers' runs have
+// finished), nothing more can happen that needs the daemon's goroutine. So, it exits,
+// and closes exitedCh. (See the exited method.)
 func (daemon *internalGroupDaemon) animate() {
 
-	toilers := make([]Toiler, 0, 8)
+	defer close(daemon.exitedCh)
 
-	toiling := false
+	var doneCh <-chan struct{}
+
+	watchdogTickCh, stopWatchdog := daemon.config.watchdog.tickCh(daemon.config.clock)
 
 	for {
 		select {
 		case lengthRequest := <-daemon.lengthCh:
-			lengthRequest.returnCh <- len(toilers)
+			daemon.serveLength(lengthRequest)
+		case pauseRequest := <-daemon.pauseCh:
+			daemon.servePause(pauseRequest)
 		case pingRequest := <-daemon.pingCh:
 			pingRequest.doneCh <- struct{}{}
 		case registrationRequest := <-daemon.registerCh:
-			toiler := registrationRequest.toiler
-
-			toilers = append(toilers, toiler)
-			if toiling {
-				daemon.spawn(toiler)
-			}
-
-			registrationRequest.doneCh <- struct{}{}
+			daemon.serveRegister(registrationRequest)
+		case registrationsRequest := <-daemon.registrationsCh:
+			daemon.serveRegistrations(registrationsRequest)
 		case toilRequest := <-daemon.toilCh:
-			if !toiling {
-				toiling = true
-				for _,toiler := range toilers {
-					daemon.spawn(toiler)
+			daemon.serveToil(toilRequest)
+		case statusRequest := <-daemon.statusCh:
+			daemon.serveStatus(statusRequest)
+		case <-watchdogTickCh:
+			daemon.watch(daemon.registrations)
+		case stopRequest := <-daemon.stopCh:
+			// NOTE that if this is before toiling, then none of the toilers will be made to toil.
+			if !daemon.stopped() {
+				close(daemon.stoppedCh)
+				daemon.cancel()
+				stopWatchdog()
+				watchdogTickCh = nil
+				if daemon.toiling {
+					for _,registration := range daemon.registrations {
+						daemon.stop(registration.toiler)
+					}
 				}
-				toilRequest.doneCh <- struct{}{}
+
+				doneCh = daemon.done()
 			}
+			stopRequest.doneCh <- struct{}{}
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+
+// done returns a channel that is closed once all the runs of the daemon's toilers
+// have finished.
+//
+// NOTE that this must only be called once the daemon has been stopped. (Before that,
+// more runs could be started after the count of runs gets to zero.)
+func (daemon *internalGroupDaemon) done() <-chan struct{} {
+	doneCh := make(chan struct{})
+
+	go func() {
+		daemon.runCounter.Wait()
+		close(doneCh)
+	}()
+
+	return doneCh
+}
+
+
+// exited serves a request (by calling `serve`) once the daemon's goroutine has exited.
+// (See the ExitedCh method.)
+func (daemon *internalGroupDaemon) exited(serve func()) {
+	daemon.exitedMutex.Lock()
+	defer daemon.exitedMutex.Unlock()
+
+	serve()
+}
+
+
+func (daemon *internalGroupDaemon) serveLength(request struct{returnCh chan int}) {
+	request.returnCh <- len(daemon.registrations)
+}
+
+
+func (daemon *internalGroupDaemon) servePause(request struct{doneCh chan struct{}; pause bool}) {
+	daemon.paused = request.pause
+	for _,registration := range daemon.registrations {
+		if daemon.paused {
+			daemon.pause(registration)
+		} else {
+			daemon.resume(registration)
+		}
+	}
+	request.doneCh <- struct{}{}
+}
+
+
+func (daemon *internalGroupDaemon) serveRegister(request struct{doneCh chan struct{}; registration *registeredToiler}) {
+	registration := request.registration
+
+	registration.index = len(daemon.registrations)
+	registration.pool  = daemon.pool(registration.config.pool)
+	if daemon.paused {
+		daemon.pause(registration)
+	}
+	daemon.registrations = append(daemon.registrations, registration)
+	if daemon.toiling && !daemon.stopped() {
+		grantChs := daemon.enqueue(registration)
+		daemon.spawn(registration, daemon.config.staggerStarts.delay(0, 1), grantChs[0])
+	}
+
+	request.doneCh <- struct{}{}
+}
+
+
+func (daemon *internalGroupDaemon) serveRegistrations(request struct{returnCh chan []*registeredToiler}) {
+	request.returnCh <- append([]*registeredToiler(nil), daemon.registrations...)
+}
+
+
+func (daemon *internalGroupDaemon) serveToil(request struct{doneCh chan struct{}}) {
+	if daemon.toiling {
+		return
+	}
+
+	daemon.toiling = true
+	if !daemon.stopped() {
+		registrations := daemon.registrations
+
+		grantChs := daemon.enqueue(registrations...)
+		for i,registration := range registrations {
+			daemon.spawn(registration, daemon.config.staggerStarts.delay(i, len(registrations)), grantChs[i])
 		}
 	}
+	request.doneCh <- struct{}{}
+}
+
+
+func (daemon *internalGroupDaemon) serveStatus(request struct{returnCh chan []ToilerStatus}) {
+	positions := map[*registeredToiler]int{}
+	for _,pool := range daemon.pools {
+		if nil == pool.scheduler {
+			continue
+		}
+		for registration,position := range pool.scheduler.positions() {
+			positions[registration] = position
+		}
+	}
+
+	statuses := make([]ToilerStatus, len(daemon.registrations))
+	for i,registration := range daemon.registrations {
+		statuses[i] = registration.status()
+		statuses[i].QueuePosition = positions[registration]
+	}
+	request.returnCh <- statuses
+}
+
+
+// stop asks a toiler to stop toiling, if it supports that (by also being a Stopper).
+func (daemon *internalGroupDaemon) stop(toiler Toiler) {
+
+	stopper, ok := toiler.(Stopper)
+	if !ok {
+		return
+	}
+
+	// We do the actual call to the toiler's Stop() method in a goroutine,
+	// since we don't want it to block or panic() here!
+	//
+	// NOTE THAT THIS IS A POTENTIAL SOURCE OF A RESOURCE LEAK!!!!!!
+	go func(stopper Stopper){
+		defer func() {
+			recover()
+		}()
+
+		stopper.Stop()
+	}(stopper)
+}
+
+
+// enqueue queues up a run for each of the registered toilers with the scheduler of
+// its pool, and returns the channels that each of them will be granted (or denied)
+// on. (See the enqueue method of scheduler.)
+//
+// The channels of registered toilers whose pool does not have a scheduler are nil.
+func (daemon *internalGroupDaemon) enqueue(registrations ...*registeredToiler) []<-chan bool {
+	grantChs := make([]<-chan bool, len(registrations))
+
+	// The runs for each pool are queued up together. (See the enqueue method of scheduler.)
+	indexes := map[*scheduler][]int{}
+	for i,registration := range registrations {
+		if scheduler := registration.pool.scheduler; nil != scheduler {
+			indexes[scheduler] = append(indexes[scheduler], i)
+		}
+	}
+
+	for scheduler,is := range indexes {
+		pooled := make([]*registeredToiler, len(is))
+		for j,i := range is {
+			pooled[j] = registrations[i]
+		}
+
+		for j,grantCh := range scheduler.enqueue(pooled...) {
+			grantChs[is[j]] = grantCh
+		}
+	}
+
+	return grantChs
+}
+
+
+// respawn makes a (registered) toiler toil again, after one of its runs finished.
+//
+// NOTE that respawn must be called before the finished run is released, so that the
+// run counter does not (even briefly) get to zero.
+func (daemon *internalGroupDaemon) respawn(registration *registeredToiler) {
+	if daemon.stopped() {
+		return
+	}
+
+	grantChs := daemon.enqueue(registration)
+	daemon.spawn(registration, 0, grantChs[0])
+}
+
+
+// restart makes a (registered) toiler toil again, after one of its runs finished,
+// and counts that as a restart.
+//
+// NOTE that restart must be called before the finished run is released, so that the
+// run counter does not (even briefly) get to zero.
+func (daemon *internalGroupDaemon) restart(registration *registeredToiler) {
+	if daemon.stopped() {
+		return
+	}
+
+	registration.countRestart()
+	daemon.emit(EventRestarted, registration, 0, nil)
+
+	delay := registration.restartDelay(daemon.config.clock.Now(), registration.pool.restartBackoff)
+	if delay <= 0 {
+		daemon.respawn(registration)
+		return
+	}
+
+	// NOTE that we wait out the backoff before the toiler is queued up with its pool's
+	// scheduler (rather than after being granted), so that it does not hold up the
+	// toilers waiting behind it. And that the run counter is incremented while we wait,
+	// so that the Group's Toil method does not return in the meantime.
+	daemon.runCounter.Add(1)
+	go func() {
+		defer daemon.runCounter.Done()
+
+		if !daemon.sleep(delay) {
+			return
+		}
+
+		daemon.respawn(registration)
+	}()
+}
+
+
+// schedulerOf returns the scheduler of the registered toiler's pool. (Or nil.)
+func (daemon *internalGroupDaemon) schedulerOf(registration *registeredToiler) *scheduler {
+	return registration.pool.scheduler
+}
+
+
+// stopped returns whether the daemon has been stopped.
+func (daemon *internalGroupDaemon) stopped() bool {
+	select {
+	case <-daemon.stoppedCh:
+		return true
+	default:
+		return false
+	}
+}
+
+
+// recovered lets a toiler know that a panic from its Toil() method was recovered from.
+func (daemon *internalGroupDaemon) recovered(toiler Toiler, panicValue interface{}) {
+
+	// At this point we see if the toiler supports us telling it that the
+	// panic from its Toil() method was recovered from.
+	//
+	// We do this by trying to cast it to another type of interface.
+	// Specifically, the recoveredNotifiableToiler interface.
+	//
+	// How the toiler's RecoveredNotice() method gets called (synchronously or
+	// asynchronously) is up to the daemon's configuration. (See the notice
+	// method of groupConfig.)
+	if notifiableToiler, ok := toiler.(recoveredNotifiableToiler); ok {
+		daemon.config.notice(func(){
+			notifiableToiler.RecoveredNotice(panicValue)
+		})
+	}
 }
 
 
-// spawn does the hard work of making a toiler toil.
-func (daemon *internalGroupDaemon) spawn(toiler Toiler) {
+// spawn does the hard work of making a (registered) toiler toil.
+//
+// The toiler is not started toiling until: it is granted on `grantCh` (if that is
+// not nil), at least `delay` has passed, and (if the daemon has a start rate limit)
+// the start rate limit allows it.
+func (daemon *internalGroupDaemon) spawn(registration *registeredToiler, delay time.Duration, grantCh <-chan bool) {
 
-	// We increment the wait group for each goroutine we spawn.
+	// We increment the run counter for each goroutine we spawn.
 	//
-	// This wait group is used by the "Group" type in its Toil()
+	// This run counter is used by the "Group" type in its Toil()
 	// method to make it so Toil() blocks (and does not return)
 	// while there are toilers still toiling.
 	//
 	// Of course, the "Group" type's Toil() method does NOT have
-	// direct access to this wait group, but instead gets indirect
+	// direct access to this run counter, but instead gets indirect
 	// access to it via this daemon's Waiter() method.
-	daemon.waitGroup.Add(1)
+	daemon.runCounter.Add(1)
 
 
 	// Spawn a goroutine, and make the toiler toil within the spawned goroutine.
-	go func(toiler Toiler){
+	go func(registration *registeredToiler){
+
+		toiler := registration.toiler
 
-		// We decrement the wait group each time a goroutine (of this type)
+		run := toilRun{
+			daemon:daemon,
+			registration:registration,
+		}
+
+		// We decrement the run counter each time a goroutine (of this type)
 		// exits, by either panic()ing or the toiler.Toil() method returning.
+		// (Or earlier, if the toiler's run timed out. See the watchTimeout method.
+		// Though the run's slot is still only released here.)
 		//
-		// This wait group is used by the "Group" type in its Toil()
+		// This run counter is used by the "Group" type in its Toil()
 		// method to make it so Toil() blocks (and does not return)
 		// while there are toilers still toiling.
 		//
 		// Of course, the "Group" type's Toil() method does NOT have
-		// direct access to this wait group, but instead gets indirect
+		// direct access to this run counter, but instead gets indirect
 		// access to it via this daemon's Waiter() method.
-		defer daemon.waitGroup.Done()
+		defer run.release()
+
+
+		// Wait until we are allowed to start the toiler toiling.
+		//
+		// If the daemon is stopped while we wait, then the toiler never toils.
+		if nil != grantCh {
+			if granted := <-grantCh; !granted {
+				return
+			}
+			run.scheduler = registration.pool.scheduler
+		}
+		if !daemon.awaitStart(delay) {
+			return
+		}
+
+
+		// The context the toiler toils with (if the toiler is a ContextToiler).
+		//
+		// If the toiler has a timeout, then we also watch for it not returning in time.
+		//
+		// NOTE that only one of these contexts is made, so that the cancel func
+		// of the other one is not lost (and does not leak).
+		var ctx    context.Context
+		var cancel context.CancelFunc
+		timeout := registration.config.timeout
+		if 0 < timeout {
+			ctx, cancel = withTimeout(daemon.ctx, daemon.config.clock, timeout)
+		} else {
+			ctx, cancel = context.WithCancel(daemon.ctx)
+		}
+		defer cancel()
+
+		run.cancel    = cancel
+		run.began     = daemon.config.clock.Now()
+		run.heartbeat = newHeartbeat(daemon.config.clock)
+		ctx = withHeartbeat(ctx, run.heartbeat)
+		ctx = daemon.withCheckpointer(ctx, registration)
+
+		// If the toiler is paused, then we do not make it toil (until it is resumed).
+		//
+		// NOTE that checking whether the toiler is paused, and making this its current
+		// run, are done together (see begin), so that a pause cannot land in between,
+		// and miss this run.
+		runNumber, began := registration.begin(&run)
+		if !began {
+			run.report()
+			daemon.park(&run)
+			return
+		}
+		defer registration.end(&run)
+
+		// NOTE that we start watching for the timeout only after the run is set up,
+		// since the watching goroutine uses it.
+		if 0 < timeout {
+			finishedCh := make(chan struct{})
+			defer close(finishedCh)
+
+			go daemon.watchTimeout(&run, timeout, finishedCh)
+		}
+
+		daemon.emit(EventStarted, registration, runNumber, nil)
+
+		// We label this goroutine (and the context) so that it can be found
+		// in the goroutine profile (such as by the dumpStacks method), and so
+		// profiles can be broken down per toiler.
+		ctx = pprof.WithLabels(ctx, daemon.labels(registration, runNumber))
+		pprof.SetGoroutineLabels(ctx)
 
 
 		// We do this so that we can capture a panic() that could happen from the
@@ -131,42 +556,108 @@ func (daemon *internalGroupDaemon) spawn(toiler Toiler) {
 		defer func() {
 			if panicValue := recover(); nil != panicValue {
 
+				// If the toiler's run already timed out, then that is what was
+				// reported (rather than this panic).
+				if !run.report() {
+					return
+				}
+
+				registration.countPanic()
+				daemon.emit(EventPanicked, registration, runNumber, panicValue)
+
+				// We capture the stack trace here, inside of the deferred func,
+				// since this is still the goroutine that panic()ed. (Once we
+				// leave here, the stack trace is lost.)
+				panicError := PanicError{
+					Value:panicValue,
+					Stack:debug.Stack(),
+					Toiler:toiler,
+					Time:daemon.config.clock.Now(),
+				}
+
 				// If we got to this point in the code, then the toiler's Toil()
 				// method has panic()ed (rather than returning gracefully).
 				//
 				// At this point we see if the toiler supports us telling it that its
 				// Toil() method panic()ed.
 				//
-				// We do this by trying to cast it to another type of interface.
-				// Specifically, the panickedNotifiableToiler interface.
+				// We do this by trying to cast it to other types of interfaces.
+				// Specifically, the panickedNotifiableToiler interface and the
+				// panickedErrorNotifiableToiler interface.
 				//
 				// This can be useful for adding in logging, tracking, etc.
 				//
-				// We do the actual call to the toiler's PanickedNotice() method
-				// in a goroutine, since we don't want it to block or panic() here!
+				// How the toiler's PanickedNotice() and PanickedErrorNotice() methods
+				// get called (synchronously or asynchronously) is up to the daemon's
+				// configuration. (See the notice method of groupConfig.)
 				//
-				// NOTE THAT THIS IS A POTENTIAL SOURCE OF A RESOURCE LEAK!!!!!!
+				// If the toiler's pool's restart policy restarts on a panic, then
+				// we recover from the panic and restart the toiler.
 				//
-				// We also make the toiler group panic() as a result of this, by
-				// panic()ing on the same panic value we recovered here.
+				// Else, we make the toiler group panic() as a result of this, by
+				// panic()ing on the same panic value we recovered here. (Or rather,
+				// the toiler group applies its panic policy to it.)
 				//
-				// We do this sending the recovered panic value on the panic channel
-				// which the group's Toil method will be listening too, and if it
-				// receives anything on it it panics on that value.
+				// We do this sending the recovered panic (as a *PanicError) on the
+				// panic channel which the group's Toil method will be listening too,
+				// and if it receives anything on it it panics on that value.
 				if notifiableToiler, ok := toiler.(panickedNotifiableToiler); ok {
-					go func(notifiableToiler panickedNotifiableToiler){
+					daemon.config.notice(func(){
 						notifiableToiler.PanickedNotice(panicValue)
-					}(notifiableToiler)
+					})
+				}
+				if notifiableToiler, ok := toiler.(panickedErrorNotifiableToiler); ok {
+					daemon.config.notice(func(){
+						notifiableToiler.PanickedErrorNotice(&panicError)
+					})
 				}
 
-				daemon.panicCh <- panicValue
+				if registration.pool.restartPolicy.restartsOnPanic() {
+					daemon.recovered(toiler, panicValue)
+					daemon.restart(registration)
+					return
+				}
+
+				daemon.panicCh <- &panicError
 			}
 		}()
 
 		// Make the toiler toil. (I.e., do work.)
 		//
 		// This method call is expected to be blocking!
-		toiler.Toil()
+		toil(ctx, toiler)
+
+
+		// If the toiler's run already timed out, then that is what was
+		// reported (rather than this return).
+		if !run.report() {
+			return
+		}
+
+		// If the toiler returned because it was paused, then we do not report
+		// it as returning, but instead wait for it to be resumed.
+		if registration.wasPaused(&run) {
+			registration.end(&run)
+			daemon.park(&run)
+			return
+		}
+
+		// If the toiler returned because it was restarted (to reload it), then we
+		// do not report it as returning, but instead make it toil again.
+		if registration.wasRestarting(&run) {
+			registration.end(&run)
+			daemon.respawn(registration)
+			return
+		}
+
+		// If the toiler returned because its deadline was exceeded, then
+		// its run still timed out.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			daemon.timedOut(&run)
+			return
+		}
+
+		daemon.emit(EventReturned, registration, runNumber, nil)
 
 
 		// If we got to this point in the code, then the toiler's Toil()
@@ -180,15 +671,200 @@ func (daemon *internalGroupDaemon) spawn(toiler Toiler) {
 		//
 		// This can be useful for adding in logging, tracking, etc.
 		//
-		// We do the actual call to the toiler's ReturnedNotice() method
-		// in a goroutine, since we don't want it to block or panic() here!
-		//
-		// NOTE THAT THIS IS A POTENTIAL SOURCE OF A RESOURCE LEAK!!!!!!
+		// How the toiler's ReturnedNotice() method gets called (synchronously or
+		// asynchronously) is up to the daemon's configuration. (See the notice
+		// method of groupConfig.)
 		if notifiableToiler, ok := toiler.(returnedNotifiableToiler); ok {
-			go func(notifiableToiler returnedNotifiableToiler){
+			daemon.config.notice(func(){
 				notifiableToiler.ReturnedNotice()
-			}(notifiableToiler)
+			})
+		}
+
+		if registration.pool.restartPolicy.restartsOnReturn() {
+			daemon.restart(registration)
+		}
+
+	}(registration)
+}
+
+
+// toilRun is a single run of a toiler (i.e., a single call to its Toil method).
+//
+// A run can finish by returning, by panic()ing, or by timing out. toilRun makes
+// sure only the first of those is reported, and that the run counter is only
+// decremented once for the run.
+type toilRun struct {
+	daemon       *internalGroupDaemon
+	registration *registeredToiler
+	scheduler    *scheduler // The scheduler that granted the run. (Or nil.)
+	cancel       context.CancelFunc
+	heartbeat    *Heartbeat
+	began        time.Time
+	number       int  // NOTE that this is protected by the registration's mutex.
+	hung         bool // NOTE that this is protected by the registration's mutex.
+	paused       bool // NOTE that this is protected by the registration's mutex.
+	restarting   bool // NOTE that this is protected by the registration's mutex.
+
+	reportOnce  sync.Once
+	countOnce   sync.Once
+	slotOnce    sync.Once
+}
+
+
+// report returns true the first time it is called, and false after that.
+func (run *toilRun) report() bool {
+	reported := false
+
+	run.reportOnce.Do(func(){
+		reported = true
+	})
+
+	return reported
+}
+
+
+// release decrements the daemon's run counter for this run, and lets the scheduler
+// that granted the run (if any) know it is done. (But only once.)
+func (run *toilRun) release() {
+	run.releaseSlot()
+	run.releaseCount()
+}
+
+
+// releaseCount decrements the daemon's run counter for this run. (But only once.)
+//
+// NOTE that this is done (without releasing the run's slot) when the daemon stops
+// waiting on a run that has not returned, such as when it timed out.
+func (run *toilRun) releaseCount() {
+	run.countOnce.Do(func(){
+		run.daemon.runCounter.Done()
+	})
+}
+
+
+// releaseSlot lets the scheduler that granted the run (if any) know it is done.
+// (But only once.)
+func (run *toilRun) releaseSlot() {
+	run.slotOnce.Do(func(){
+		if nil != run.scheduler {
+			run.scheduler.release()
+		}
+	})
+}
+
+
+// watchTimeout marks a toiler's run as timed out, if it does not finish within `timeout`.
+//
+// Marking a toiler's run as timed out means that: the daemon stops waiting on it, its
+// TimedOutNotice method is called (if it has one), and a *PanicError (whose Value is a
+// *TimeoutError) is sent on the panic channel (so that the group applies its panic
+// policy to it).
+//
+// NOTE that the run keeps its slot in its pool's concurrency limit (if any) until it
+// actually returns. (A toiler that is not a ContextToiler cannot be made to return.)
+// Otherwise, a run restarted after timing out would toil alongside the run that is
+// still hung, and repeated timeouts would go past the limit.
+func (daemon *internalGroupDaemon) watchTimeout(run *toilRun, timeout time.Duration, finishedCh <-chan struct{}) {
+
+	timerCh, stopTimer := daemon.config.clock.NewTimer(timeout)
+	defer stopTimer()
+
+	select {
+	case <-finishedCh:
+		return
+	case <-timerCh:
+	}
+
+	if !run.report() {
+		return
+	}
+
+	run.cancel()
+
+	daemon.timedOut(run)
+
+	run.releaseCount()
+}
+
+
+// timedOut reports that a toiler's run timed out.
+func (daemon *internalGroupDaemon) timedOut(run *toilRun) {
+
+	registration := run.registration
+
+	toiler  := registration.toiler
+	timeout := registration.config.timeout
+
+	registration.countTimedOut()
+
+	if notifiableToiler, ok := toiler.(timedOutNotifiableToiler); ok {
+		daemon.config.notice(func(){
+			notifiableToiler.TimedOutNotice()
+		})
+	}
+
+	timeoutError := TimeoutError{
+		Toiler:toiler,
+		Timeout:timeout,
+	}
+
+	daemon.emit(EventTimedOut, registration, run.number, &timeoutError)
+
+	// If the toiler's pool's restart policy restarts on a panic, then we also
+	// restart on a time out.
+	if registration.pool.restartPolicy.restartsOnPanic() {
+		daemon.recovered(toiler, &timeoutError)
+		daemon.restart(registration)
+		return
+	}
+
+	daemon.panicCh <- &PanicError{
+		Value:&timeoutError,
+		Toiler:toiler,
+		Time:daemon.config.clock.Now(),
+	}
+}
+
+
+// awaitStart blocks until a toiler is allowed to start toiling. I.e., until `delay`
+// has passed and the start rate limit (if there is one) allows it.
+//
+// It returns false (early) if the daemon was stopped while waiting, and true otherwise.
+func (daemon *internalGroupDaemon) awaitStart(delay time.Duration) bool {
+	if !daemon.sleep(delay) {
+		return false
+	}
+
+	if nil != daemon.startLimiter {
+		if !daemon.sleep(daemon.startLimiter.reserve()) {
+			return false
 		}
+	}
+
+	return true
+}
+
 
-	}(toiler)
+// sleep blocks for `duration`.
+//
+// It returns false (early) if the daemon was stopped while sleeping, and true otherwise.
+func (daemon *internalGroupDaemon) sleep(duration time.Duration) bool {
+	if duration <= 0 {
+		select {
+		case <-daemon.stoppedCh:
+			return false
+		default:
+			return true
+		}
+	}
+
+	timerCh, stopTimer := daemon.config.clock.NewTimer(duration)
+	defer stopTimer()
+
+	select {
+	case <-daemon.stoppedCh:
+		return false
+	case <-timerCh:
+		return true
+	}
 }
@@ -6,17 +6,17 @@ import (
 
 	"github.com/reiver/go-toil/toiltest"
 
+	"context"
 	"math/rand"
-	"sync"
 	"time"
 )
 
 
 func TestNewGroupDaemon(t *testing.T) {
 
-	panicCh := make(chan interface{})
+	panicCh := make(chan *PanicError)
 
-	daemon := newGroupDaemon(panicCh)
+	daemon := newGroupDaemon(panicCh, newGroupConfig())
 	if nil == daemon {
 		t.Errorf("After creating a new daemon, expected it to not be nil, but it was: %v", daemon)
 		return
@@ -40,9 +40,9 @@ func TestNewGroupDaemon(t *testing.T) {
 
 func TestPingCh(t *testing.T) {
 
-	panicCh := make(chan interface{})
+	panicCh := make(chan *PanicError)
 
-	daemon := newGroupDaemon(panicCh)
+	daemon := newGroupDaemon(panicCh, newGroupConfig())
 
 	const NUM_PING_TESTS = 20
 	doneCh := make(chan struct{})
@@ -58,9 +58,9 @@ func TestPingCh(t *testing.T) {
 
 func TestLengthCh(t *testing.T) {
 
-	panicCh := make(chan interface{})
+	panicCh := make(chan *PanicError)
 
-	daemon := newGroupDaemon(panicCh)
+	daemon := newGroupDaemon(panicCh, newGroupConfig())
 
 	lengthReturnCh := make(chan int)
 	daemon.LengthCh() <- struct{returnCh chan int}{
@@ -79,16 +79,16 @@ func TestRegisterCh(t *testing.T) {
 
 	toiler := toiltest.NewRecorder()
 
-	panicCh := make(chan interface{})
+	panicCh := make(chan *PanicError)
 
-	daemon := newGroupDaemon(panicCh)
+	daemon := newGroupDaemon(panicCh, newGroupConfig())
 
 	const NUM_REGISTER_TESTS = 20
 	doneCh := make(chan struct{})
 	for testNumber:=0; testNumber<NUM_REGISTER_TESTS; testNumber++ {
-		daemon.RegisterCh() <- struct{doneCh chan struct{}; toiler Toiler}{
+		daemon.RegisterCh() <- struct{doneCh chan struct{}; registration *registeredToiler}{
 			doneCh:doneCh,
-			toiler:toiler,
+			registration:&registeredToiler{toiler:toiler},
 		}
 		<-doneCh // THE TEST WE ARE DOING IS MAKING SURE THIS DOES NOT RESULT IN A DEADLOCK.
 		         // THUS WE ARE NOT CALLING ANYTHING LIKE t.Errorf() IN THIS CASE.
@@ -111,7 +111,12 @@ func TestRegisterCh(t *testing.T) {
 func TestToilCh(t *testing.T) {
 
 	// Initialize.
-	randomness := rand.New( rand.NewSource( time.Now().UTC().UnixNano() ) )
+	//
+	// NOTE that the seed is fixed (rather than from the time) so that this test is deterministic.
+	const seed = 20161019
+	randomness := rand.New( rand.NewSource(seed) )
+
+	clock := toiltest.NewFakeClock(time.Date(2016, 10, 19, 0, 0, 0, 0, time.UTC))
 
 
 	// Do tests.
@@ -121,25 +126,19 @@ func TestToilCh(t *testing.T) {
 
 		numberOfTimesToToil := randomness.Intn(44)
 
-		var waitGroup sync.WaitGroup
-		waitGroup.Add(numberOfTimesToToil)
-
 		toiler := toiltest.NewRecorder()
-		toiler.ToilFunc(func(){
-			waitGroup.Done()
-		})
 
 
-		panicCh := make(chan interface{})
+		panicCh := make(chan *PanicError)
 
-		daemon := newGroupDaemon(panicCh)
+		daemon := newGroupDaemon(panicCh, newGroupConfig(WithClock(clock)))
 
 
 		for i:=0; i<numberOfTimesToToil; i++ {
 
-			daemon.RegisterCh() <- struct{doneCh chan struct{}; toiler Toiler}{
+			daemon.RegisterCh() <- struct{doneCh chan struct{}; registration *registeredToiler}{
 				doneCh:doneCh,
-				toiler:toiler,
+				registration:&registeredToiler{toiler:toiler},
 			}
 			<-doneCh // THE TEST WE ARE DOING IS MAKING SURE THIS DOES NOT RESULT IN A DEADLOCK.
 			         // THUS WE ARE NOT CALLING ANYTHING LIKE t.Errorf() IN THIS CASE.
@@ -153,7 +152,11 @@ func TestToilCh(t *testing.T) {
 		         // THUS WE ARE NOT CALLING ANYTHING LIKE t.Errorf() IN THIS CASE.
 
 
-		waitGroup.Wait() // Make sure all the calls on the Toil() method are done before continuing.
+		// Make sure all the calls on the Toil() method are done before continuing.
+		if !toiler.WaitForToiling(numberOfTimesToToil, 5*time.Second) {
+			t.Errorf("For test #%d, expected the number of toiling toilers to get to %d, but it did not, and actually was %d.", testNumber, numberOfTimesToToil, toiler.NumToiling())
+			continue
+		}
 
 
 		if expected, actual := numberOfTimesToToil, toiler.NumToiling(); expected != actual {
@@ -162,3 +165,94 @@ func TestToilCh(t *testing.T) {
 		}
 	}
 }
+
+
+func TestDaemonExitsWhenDone(t *testing.T) {
+
+	toiltest.AssertNoGoroutineLeaks(t)
+
+	blockCh := make(chan struct{})
+
+	group := NewGroup(MaxConcurrency(1))
+	for i:=0; i<3; i++ {
+		group.Register(ContextToilerFunc(func(ctx context.Context) {
+			select {
+			case <-ctx.Done():
+			case <-blockCh:
+			}
+		}))
+	}
+
+	toilDoneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(toilDoneCh)
+	}()
+
+	daemon := group.(*internalGroup).daemon
+	scheduler := daemon.pools[""].scheduler
+
+	toiltest.AssertEventually(t, time.Second, func() bool {
+		return 2 == group.Status()[2].QueuePosition
+	})
+
+	group.Stop()
+
+	select {
+	case <-toilDoneCh:
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected Toil() to return, but it did not.")
+	}
+
+	for _,exitedCh := range []<-chan struct{}{daemon.ExitedCh(), scheduler.exitedCh} {
+		select {
+		case <-exitedCh:
+		case <-time.After(5*time.Second):
+			t.Fatalf("Expected the goroutines of the daemon and the scheduler to exit, but they did not.")
+		}
+	}
+
+	// Once the daemon's goroutine has exited, the Group still answers.
+	group.RegisterWith(ToilerFunc(func(){
+		t.Errorf("Did not expect a toiler registered after the Group was done to toil, but it did.")
+	}), InPool("other"))
+	if expected, actual := 4, group.Len(); expected != actual {
+		t.Errorf("Expected the number of registered toilers to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 4, len(group.Status()); expected != actual {
+		t.Errorf("Expected the number of statuses to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 4, len(group.Reload(context.Background())); expected != actual {
+		t.Errorf("Expected the number of reload outcomes to be %d, but actually was %d.", expected, actual)
+	}
+	group.Pause()
+	if !group.Status()[3].Paused {
+		t.Errorf("Expected the toiler to be paused, but it was not.")
+	}
+	group.Resume()
+	group.Stop()
+	close(blockCh)
+}
+
+
+func TestDaemonExitsWhenStoppedBeforeToil(t *testing.T) {
+
+	toiltest.AssertNoGoroutineLeaks(t)
+
+	group := NewGroup()
+	group.Register(ToilerFunc(func(){
+		t.Errorf("Did not expect a toiler of a Group stopped before Toil() to toil, but it did.")
+	}))
+	group.Stop()
+
+	select {
+	case <-group.(*internalGroup).daemon.ExitedCh():
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected the goroutine of the daemon to exit, but it did not.")
+	}
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+	}
+}
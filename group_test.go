@@ -8,6 +8,7 @@ import (
 
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +24,43 @@ func TestNewGroup(t *testing.T) {
 }
 
 
+// lenRegisterToilGroup only has the Len, Register and Toil methods.
+type lenRegisterToilGroup struct{}
+
+
+func (lenRegisterToilGroup) Len() int {
+	return 0
+}
+
+
+func (lenRegisterToilGroup) Register(Toiler) {
+}
+
+
+func (lenRegisterToilGroup) Toil() {
+}
+
+
+func TestGroupInterface(t *testing.T) {
+
+	// NOTE that code that implements Group itself (such as a mock of it) only needs the
+	// Len, Register and Toil methods.
+	var group Group = lenRegisterToilGroup{}
+
+	if _, ok := group.(Supervisor); ok {
+		t.Errorf("Expected a Group with only the Len, Register and Toil methods to not be a Supervisor, but it was.")
+	}
+
+	group = NewGroup()
+
+	supervisor, ok := group.(Supervisor)
+	if !ok {
+		t.Fatalf("Expected the Group that NewGroup returns to be a Supervisor, but it was not.")
+	}
+	supervisor.Stop()
+}
+
+
 func TestLen(t *testing.T) {
 
 	group := NewGroup()
@@ -77,7 +115,10 @@ func TestRegister(t *testing.T) {
 func TestToil(t *testing.T) {
 
 	// Initialize.
-	randomness := rand.New( rand.NewSource( time.Now().UTC().UnixNano() ) )
+	//
+	// NOTE that the seed is fixed (rather than from the time) so that this test is deterministic.
+	const seed = 20161019
+	randomness := rand.New( rand.NewSource(seed) )
 
 
 	// Do tests.
@@ -86,13 +127,7 @@ func TestToil(t *testing.T) {
 
 		numberOfTimesToToil := randomness.Intn(44)
 
-		var waitGroup sync.WaitGroup
-		waitGroup.Add(numberOfTimesToToil)
-
 		toiler := toiltest.NewRecorder()
-		toiler.ToilFunc(func(){
-			waitGroup.Done()
-		})
 
 
 		group := NewGroup()
@@ -106,7 +141,11 @@ func TestToil(t *testing.T) {
 		go group.Toil()
 
 
-		waitGroup.Wait() // Make sure all the calls on the Toil() method are done before continuing.
+		// Make sure all the calls on the Toil() method are done before continuing.
+		if !toiler.WaitForToiling(numberOfTimesToToil, 5*time.Second) {
+			t.Errorf("For test #%d, expected the number of toiling toilers to get to %d, but it did not, and actually was %d.", testNumber, numberOfTimesToToil, toiler.NumToiling())
+			continue
+		}
 
 
 		if expected, actual := numberOfTimesToToil, toiler.NumToiling(); expected != actual {
@@ -120,7 +159,10 @@ func TestToil(t *testing.T) {
 func TestToilPanicked(t *testing.T) {
 
 	// Initialize.
-	randomness := rand.New( rand.NewSource( time.Now().UTC().UnixNano() ) )
+	//
+	// NOTE that the seed is fixed (rather than from the time) so that this test is deterministic.
+	const seed = 20161019
+	randomness := rand.New( rand.NewSource(seed) )
 
 
 	// Do test.
@@ -198,3 +240,58 @@ func TestToilPanicked(t *testing.T) {
 		t.Errorf("Expected number of times panicked to be %d, but actually was %d.", expected, actual)
 	}
 }
+
+
+type panickedErrorRecorder struct {
+	panicValue interface{}
+	noticeCh   chan *PanicError
+}
+
+func (toiler *panickedErrorRecorder) Toil() {
+	panic(toiler.panicValue)
+}
+
+func (toiler *panickedErrorRecorder) PanickedErrorNotice(err *PanicError) {
+	toiler.noticeCh <- err
+}
+
+
+func TestToilPanickedErrorNotice(t *testing.T) {
+
+	toiler := panickedErrorRecorder{
+		panicValue:"Panic Value for the PanickedErrorNotice test",
+		noticeCh:make(chan *PanicError, 1),
+	}
+
+	group := NewGroup()
+	group.Register(&toiler)
+
+	go func() {
+		defer func() {
+			recover()
+		}()
+
+		group.Toil()
+	}()
+
+	var panicError *PanicError
+	select {
+	case panicError = <-toiler.noticeCh:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected PanickedErrorNotice() to be called, but it was not.")
+		return
+	}
+
+	if expected, actual := toiler.panicValue, panicError.Value; expected != actual {
+		t.Errorf("Expected panic value to be [%v], but actually was [%v].", expected, actual)
+	}
+	if expected, actual := Toiler(&toiler), panicError.Toiler; expected != actual {
+		t.Errorf("Expected toiler to be [%v], but actually was [%v].", expected, actual)
+	}
+	if !strings.Contains(string(panicError.Stack), "panickedErrorRecorder") {
+		t.Errorf("Expected stack trace to mention the toiler's Toil method, but it did not: %s", panicError.Stack)
+	}
+	if panicError.Time.IsZero() {
+		t.Errorf("Expected panic time to be set, but it was not.")
+	}
+}
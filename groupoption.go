@@ -0,0 +1,64 @@
+package toil
+
+
+import (
+	"time"
+)
+
+
+// GroupOption is an option that can be passed to NewGroup, to configure
+// the Group that it returns.
+//
+// For example:
+//
+//	group := toil.NewGroup(toil.Isolate)
+type GroupOption interface {
+	applyGroupOption(*groupConfig)
+}
+
+
+// groupConfig is the configuration of a Group, as built up from the GroupOption(s)
+// passed to NewGroup.
+type groupConfig struct {
+	name string
+
+	clock Clock
+
+	panicPolicy PanicPolicy
+
+	synchronousNotices bool
+	noticeTimeout      time.Duration
+
+	startRate     startRateOption
+	staggerStarts staggerStartsOption
+
+	maxConcurrency int
+	restartPolicy  RestartPolicy
+	restartBackoff restartBackoffOption
+	pools          map[string]groupConfig
+
+	eventChs []chan<- Event
+	journals []Journal
+
+	checkpointer Checkpointer
+
+	watchdog watchdogOption
+}
+
+
+func newGroupConfig(options ...GroupOption) groupConfig {
+	config := groupConfig{
+		clock:realClock{},
+		panicPolicy:Propagate,
+		restartBackoff:defaultRestartBackoff,
+	}
+
+	for _,option := range options {
+		if nil == option {
+			continue
+		}
+		option.applyGroupOption(&config)
+	}
+
+	return config
+}
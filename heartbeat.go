@@ -0,0 +1,107 @@
+package toil
+
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+
+// Heartbeat is a handle a toiler uses to let its Group know that it is still alive
+// (i.e., that it has not deadlocked, or otherwise hung).
+//
+// A toiler that is a ContextToiler gets its Heartbeat from the context passed to its
+// ToilContext method, with HeartbeatFromContext. For example:
+//
+//	func (toiler *awesomeToiler) ToilContext(ctx context.Context) {
+//		heartbeat := toil.HeartbeatFromContext(ctx)
+//	
+//		for {
+//			heartbeat.Beat()
+//	
+//			//@TODO: Do a unit of work here.
+//		}
+//	}
+//
+// Only toilers that get their Heartbeat are watched by the Group's Watchdog.
+type Heartbeat struct {
+	mutex sync.Mutex
+	clock Clock
+	last  time.Time
+	used  bool
+}
+
+
+type heartbeatContextKey struct{}
+
+
+func newHeartbeat(clock Clock) *Heartbeat {
+	heartbeat := Heartbeat{
+		clock:clock,
+		last:clock.Now(),
+	}
+
+	return &heartbeat
+}
+
+
+// HeartbeatFromContext returns the Heartbeat in the context passed to a toiler's
+// ToilContext method.
+//
+// If there is no Heartbeat in the context, then it returns nil. (Calling Beat on
+// a nil *Heartbeat does nothing, so it is safe to do so.)
+func HeartbeatFromContext(ctx context.Context) *Heartbeat {
+	heartbeat, _ := ctx.Value(heartbeatContextKey{}).(*Heartbeat)
+	if nil == heartbeat {
+		return nil
+	}
+
+	heartbeat.mutex.Lock()
+	heartbeat.used = true
+	heartbeat.mutex.Unlock()
+
+	return heartbeat
+}
+
+
+func withHeartbeat(ctx context.Context, heartbeat *Heartbeat) context.Context {
+	return context.WithValue(ctx, heartbeatContextKey{}, heartbeat)
+}
+
+
+// Beat lets the Group know that the toiler is still alive.
+func (heartbeat *Heartbeat) Beat() {
+	if nil == heartbeat {
+		return
+	}
+
+	heartbeat.mutex.Lock()
+	defer heartbeat.mutex.Unlock()
+
+	heartbeat.last = heartbeat.clock.Now()
+}
+
+
+// Last returns when Beat was last called. (Or when the toiler started toiling,
+// if Beat has not been called yet.)
+func (heartbeat *Heartbeat) Last() time.Time {
+	if nil == heartbeat {
+		return time.Time{}
+	}
+
+	heartbeat.mutex.Lock()
+	defer heartbeat.mutex.Unlock()
+
+	return heartbeat.last
+}
+
+
+// watched returns when Beat was last called, and whether the toiler got its
+// heartbeat (and is thus watched by the watchdog).
+func (heartbeat *Heartbeat) watched() (time.Time, bool) {
+	heartbeat.mutex.Lock()
+	defer heartbeat.mutex.Unlock()
+
+	return heartbeat.last, heartbeat.used
+}
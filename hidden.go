@@ -25,3 +25,44 @@ type panickedNotifiableToiler interface {
 	Toiler
 	PanickedNotice(interface{})
 }
+
+
+// panickedErrorNotifiableToiler is an interface that wraps the Toil and PanickedErrorNotice methods.
+//
+// The purpose of the Toil method is to do work.
+// The Toil method should block while it is doing work.
+//
+// The purpose of the PanickedErrorNotice method is as a means of notifying when
+// the Toil method panic()ed, with more information than the PanickedNotice method
+// gets. (I.e., the stack trace, the toiler and when it happened.)
+type panickedErrorNotifiableToiler interface {
+	Toiler
+	PanickedErrorNotice(*PanicError)
+}
+
+
+// recoveredNotifiableToiler is an interface that wraps the Toil and RecoveredNotice methods.
+//
+// The purpose of the Toil method is to do work.
+// The Toil method should block while it is doing work.
+//
+// The purpose of the RecoveredNotice method is as a means of notifying when
+// the Toil method panic()ed, and the Group recovered from that panic (rather
+// than propagating it), due to its PanicPolicy.
+type recoveredNotifiableToiler interface {
+	Toiler
+	RecoveredNotice(interface{})
+}
+
+
+// timedOutNotifiableToiler is an interface that wraps the Toil and TimedOutNotice methods.
+//
+// The purpose of the Toil method is to do work.
+// The Toil method should block while it is doing work.
+//
+// The purpose of the TimedOutNotice method is as a means of notifying when
+// the Toil method did not return before its timeout. (See Timeout.)
+type timedOutNotifiableToiler interface {
+	Toiler
+	TimedOutNotice()
+}
@@ -0,0 +1,224 @@
+package toil
+
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+
+// Journal is an interface that wraps the WriteEvent method.
+//
+// A Group with a Journal (see WithJournal) writes every Event (i.e., every start,
+// return, panic, time out, restart, pause and resume of its toilers) to it. So that,
+// for example, after a process crashes, it can be seen which toiler panic()ed last,
+// and how often. (See NewFileJournal, ReadJournal and ReplayJournal.)
+//
+// WriteEvent is called synchronously (so that an Event is written before, for example,
+// the ExitProcess PanicPolicy exits the process), from many goroutines at the same time.
+// So it should be quick, and safe for concurrent use.
+//
+// The Group ignores any error WriteEvent returns.
+type Journal interface {
+	WriteEvent(Event) error
+}
+
+
+// WithJournal returns a GroupOption that makes the Group write its Events to `journal`.
+// (See Journal.)
+//
+// NOTE that a journal identifies toilers by their names. So, give the toilers unique
+// names. (See Name.)
+func WithJournal(journal Journal) GroupOption {
+	return journalOption{
+		journal:journal,
+	}
+}
+
+
+type journalOption struct {
+	journal Journal
+}
+
+
+func (option journalOption) applyGroupOption(config *groupConfig) {
+	if nil == option.journal {
+		return
+	}
+
+	config.journals = append(config.journals, option.journal)
+}
+
+
+// journal writes an event to the daemon's journals.
+func (daemon *internalGroupDaemon) journal(event Event) {
+	for _,journal := range daemon.config.journals {
+		func() {
+			// We do not want a journal that panic()s to take down the daemon.
+			defer func() {
+				recover()
+			}()
+
+			journal.WriteEvent(event)
+		}()
+	}
+}
+
+
+// journalRecord is how an Event is encoded, as a line of JSON, in a journal.
+type journalRecord struct {
+	Kind   string    `json:"kind"`
+	Time   time.Time `json:"time"`
+	Group  string    `json:"group,omitempty"`
+	Toiler string    `json:"toiler"`
+	Pool   string    `json:"pool,omitempty"`
+	Run    int       `json:"run,omitempty"`
+	Value  string    `json:"value,omitempty"`
+}
+
+
+func newJournalRecord(event Event) journalRecord {
+	record := journalRecord{
+		Kind:event.Kind.String(),
+		Time:event.Time,
+		Group:event.Group,
+		Toiler:event.Toiler,
+		Pool:event.Pool,
+		Run:event.Run,
+	}
+
+	if nil != event.Value {
+		record.Value = fmt.Sprint(event.Value)
+	}
+
+	return record
+}
+
+
+func (record journalRecord) event() (Event, error) {
+	kind, err := parseEventKind(record.Kind)
+	if nil != err {
+		return Event{}, err
+	}
+
+	event := Event{
+		Kind:kind,
+		Time:record.Time,
+		Group:record.Group,
+		Toiler:record.Toiler,
+		Pool:record.Pool,
+		Run:record.Run,
+	}
+
+	if "" != record.Value {
+		event.Value = record.Value
+	}
+
+	return event, nil
+}
+
+
+// parseEventKind is the inverse of the String method of EventKind.
+func parseEventKind(s string) (EventKind, error) {
+	for kind := EventStarted; kind <= EventResumed; kind++ {
+		if kind.String() == s {
+			return kind, nil
+		}
+	}
+
+	return 0, fmt.Errorf("toil: unknown event kind %q", s)
+}
+
+
+// ReadJournal reads the Events written (as lines of JSON) to a journal. (For example,
+// by a journal returned by NewFileJournal.)
+//
+// The Value of the Events read is the string of the Value written. (I.e., the panic
+// value, or the *TimeoutError, formatted with fmt.Sprint.)
+//
+// A last line that is incomplete (for example, because the process crashed while
+// writing it) is ignored. As is any other line that is not valid JSON (for example,
+// an incomplete line that was ended when the journal was opened again).
+func ReadJournal(reader io.Reader) ([]Event, error) {
+	var events []Event
+
+	bufferedReader := bufio.NewReader(reader)
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := bufferedReader.ReadBytes('\n')
+		if io.EOF == err {
+			// NOTE that a last line without a newline is incomplete.
+			return events, nil
+		}
+		if nil != err {
+			return events, err
+		}
+
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); nil != err {
+			continue
+		}
+
+		event, err := record.event()
+		if nil != err {
+			return events, fmt.Errorf("toil: journal line %d: %w", lineNumber, err)
+		}
+
+		events = append(events, event)
+	}
+}
+
+
+// ReplayJournal replays Events (for example, as read with ReadJournal) into the status
+// of each toiler of the Group named `group`, in the order the toilers first appear.
+//
+// Since a journal only has Events, only the Name, Pool, Toiling, Runs, Panics, Restarts,
+// TimedOut and Paused fields of the statuses are filled in. After a process crashed,
+// Toiling is whether the toiler was toiling when the process crashed.
+func ReplayJournal(events []Event, group string) []ToilerStatus {
+	var statuses []ToilerStatus
+	indexes := map[string]int{}
+
+	for _,event := range events {
+		if group != event.Group {
+			continue
+		}
+
+		index, ok := indexes[event.Toiler]
+		if !ok {
+			index = len(statuses)
+			indexes[event.Toiler] = index
+			statuses = append(statuses, ToilerStatus{
+				Name:event.Toiler,
+				Pool:event.Pool,
+			})
+		}
+
+		status := &statuses[index]
+
+		switch event.Kind {
+		case EventStarted:
+			status.Runs++
+			status.Toiling = true
+		case EventReturned:
+			status.Toiling = false
+		case EventPanicked:
+			status.Panics++
+			status.Toiling = false
+		case EventTimedOut:
+			status.TimedOut++
+			status.Toiling = false
+		case EventRestarted:
+			status.Restarts++
+		case EventPaused:
+			status.Paused = true
+		case EventResumed:
+			status.Paused = false
+		}
+	}
+
+	return statuses
+}
@@ -0,0 +1,198 @@
+package toil
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil/toiltest"
+
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+
+func TestFileJournal(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "toil.jsonl")
+
+	journal, err := NewFileJournal(path)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer journal.Close()
+
+	toiler := toiltest.NewScriptedToiler(
+		toiltest.Script{toiltest.Panic("apple")},
+		toiltest.Script{toiltest.Return(nil)},
+	)
+
+	group := NewGroup(Name("workers"), Isolate, RestartOnPanic, SynchronousNotices(0), WithJournal(journal))
+	group.RegisterWith(toiler, Name("worker"))
+	group.RegisterWith(toiltest.NewScriptedToiler(), Name("idler"))
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Fatalf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+	}
+
+	file, err := os.Open(path)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer file.Close()
+
+	events, err := ReadJournal(file)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	var panicked *Event
+	for i := range events {
+		if EventPanicked == events[i].Kind {
+			panicked = &events[i]
+		}
+	}
+	if nil == panicked {
+		t.Fatalf("Expected a panicked event in the journal, but there was not one: %#v", events)
+	}
+	if expected, actual := "worker", panicked.Toiler; expected != actual {
+		t.Errorf("Expected the toiler that panicked to be %q, but actually was %q.", expected, actual)
+	}
+	if expected, actual := "apple", panicked.Value; expected != actual {
+		t.Errorf("Expected the panic value to be %q, but actually was %v.", expected, actual)
+	}
+
+	statuses := ReplayJournal(events, "workers")
+	if expected, actual := 2, len(statuses); expected != actual {
+		t.Fatalf("Expected the number of statuses to be %d, but actually was %d: %#v", expected, actual, statuses)
+	}
+
+	var worker ToilerStatus
+	for _,status := range statuses {
+		if "worker" == status.Name {
+			worker = status
+		}
+	}
+
+	expected := ToilerStatus{
+		Name:"worker",
+		Runs:2,
+		Panics:1,
+		Restarts:1,
+	}
+	if actual := worker; expected != actual {
+		t.Errorf("Expected the replayed status to be %#v, but actually was %#v.", expected, actual)
+	}
+}
+
+
+func TestReadJournalCrashed(t *testing.T) {
+
+	journal := `{"kind":"started","time":"2016-10-19T00:00:00Z","toiler":"worker","run":1}
+{"kind":"panicked","time":"2016-10-19T00:00:01Z","toiler":"worker","run":1,"value":"apple"}
+{"kind":"restarted","time":"2016-10-19T00:00:01Z","toiler":"worker"}
+{"kind":"started","time":"2016-10-19T00:00:01Z","toiler":"worker","run":2}
+{"kind":"returned","time":"2016-10-19T00:0`
+
+	events, err := ReadJournal(strings.NewReader(journal))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	if expected, actual := 4, len(events); expected != actual {
+		t.Fatalf("Expected the number of events to be %d, but actually was %d.", expected, actual)
+	}
+
+	statuses := ReplayJournal(events, "")
+	if expected, actual := 1, len(statuses); expected != actual {
+		t.Fatalf("Expected the number of statuses to be %d, but actually was %d.", expected, actual)
+	}
+
+	expected := ToilerStatus{
+		Name:"worker",
+		Toiling:true,
+		Runs:2,
+		Panics:1,
+		Restarts:1,
+	}
+	if actual := statuses[0]; expected != actual {
+		t.Errorf("Expected the replayed status to be %#v, but actually was %#v.", expected, actual)
+	}
+}
+
+
+func TestReadJournalBadKind(t *testing.T) {
+
+	_, err := ReadJournal(strings.NewReader(`{"kind":"exploded","time":"2016-10-19T00:00:00Z","toiler":"worker"}` + "\n"))
+	if nil == err {
+		t.Errorf("Expected an error, but did not actually get one.")
+	}
+}
+
+
+func TestFileJournalAfterCrash(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "toil.jsonl")
+
+	// A journal whose process crashed while writing its last line.
+	crashed := `{"kind":"started","time":"2016-10-19T00:00:00Z","group":"workers","toiler":"worker","run":1}
+{"kind":"returned","time":"2016-10-19T00:0`
+	if err := os.WriteFile(path, []byte(crashed), 0644); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	for i:=0; i<2; i++ {
+		journal, err := NewFileJournal(path)
+		if nil != err {
+			t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+		}
+
+		err = journal.WriteEvent(Event{Kind:EventStarted, Time:time.Now(), Group:"workers", Toiler:"worker", Run:2+i})
+		if nil != err {
+			t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+		}
+
+		journal.Close()
+	}
+
+	file, err := os.Open(path)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer file.Close()
+
+	events, err := ReadJournal(file)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	if expected, actual := 3, len(events); expected != actual {
+		t.Fatalf("Expected the number of events to be %d, but actually was %d: %#v", expected, actual, events)
+	}
+
+	for i,event := range events {
+		if expected, actual := i+1, event.Run; expected != actual {
+			t.Errorf("Expected the run of event #%d to be %d, but actually was %d.", i, expected, actual)
+		}
+	}
+}
+
+
+func TestReadJournalSkipsMalformedLines(t *testing.T) {
+
+	journal := `{"kind":"started","time":"2016-10-19T00:00:00Z","toiler":"worker","run":1}
+{"kind":"panicked","time":"2016-10-19T00:00:01Z","toi
+{"kind":"started","time":"2016-10-19T00:00:01Z","toiler":"worker","run":2}
+`
+
+	events, err := ReadJournal(strings.NewReader(journal))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	if expected, actual := 2, len(events); expected != actual {
+		t.Fatalf("Expected the number of events to be %d, but actually was %d.", expected, actual)
+	}
+}
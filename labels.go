@@ -0,0 +1,110 @@
+package toil
+
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+)
+
+
+// The pprof labels that the goroutines toilers toil in are labelled with.
+//
+// These can be used to break down CPU and goroutine profiles per toiler, with
+// `go tool pprof`. For example:
+//
+//	go tool pprof -tagfocus=toil.toiler=mailer cpu.prof
+//
+// The group ID label is what DumpStacks uses to find the goroutines of a Group's toilers.
+const (
+	groupIDLabel = "toil.group.id"
+	groupLabel   = "toil.group"
+	toilerLabel  = "toil.toiler"
+	runLabel     = "toil.run"
+)
+
+
+// lastGroupID is used to give each group daemon a unique ID (for its pprof labels).
+var lastGroupID int64
+
+
+func nextGroupID() string {
+	return strconv.FormatInt(atomic.AddInt64(&lastGroupID, 1), 10)
+}
+
+
+// Name is used to name a Group or a toiler. Name is both a GroupOption and a RegisterOption.
+// For example:
+//
+//	group := toil.NewGroup(toil.Name("workers"))
+//	
+//	group.RegisterWith(toiler, toil.Name("mailer"))
+//
+// The names are used for the pprof labels of the goroutines toilers toil in, and in the
+// Group's Status.
+//
+// A Group that is not given a Name is named after its (unique) ID. A toiler that is not
+// given a Name is named after its type.
+type Name string
+
+
+func (name Name) applyGroupOption(config *groupConfig) {
+	config.name = string(name)
+}
+
+
+func (name Name) applyRegisterOption(config *registerConfig) {
+	config.name = string(name)
+}
+
+
+// name returns the name of the daemon's group.
+func (daemon *internalGroupDaemon) name() string {
+	if "" == daemon.config.name {
+		return daemon.id
+	}
+
+	return daemon.config.name
+}
+
+
+// name returns the name of the registered toiler.
+func (registration *registeredToiler) name() string {
+	if "" == registration.config.name {
+		return fmt.Sprintf("%T", registration.toiler)
+	}
+
+	return registration.config.name
+}
+
+
+// labels returns the pprof labels for the goroutine a registered toiler toils in (for a run).
+func (daemon *internalGroupDaemon) labels(registration *registeredToiler, runNumber int) pprof.LabelSet {
+	return pprof.Labels(
+		groupIDLabel, daemon.id,
+		groupLabel, daemon.name(),
+		toilerLabel, registration.name(),
+		runLabel, strconv.Itoa(runNumber),
+	)
+}
+
+
+// Go calls fn in a new goroutine, labelled with the pprof labels in ctx.
+//
+// A toiler that is a ContextToiler should use Go (with the context passed to its
+// ToilContext method) to start any goroutines it uses to do its work, so that
+// those goroutines get the same pprof labels as the toiler. (I.e., the group name,
+// the toiler name and the run number.) For example:
+//
+//	func (toiler *awesomeToiler) ToilContext(ctx context.Context) {
+//		toil.Go(ctx, func(ctx context.Context) {
+//			//@TODO: Do work here.
+//		})
+//	
+//		//@TODO: Do work here.
+//	}
+func Go(ctx context.Context, fn func(context.Context)) {
+	go pprof.Do(ctx, pprof.Labels(), fn)
+}
@@ -0,0 +1,88 @@
+package toil
+
+
+import (
+	"testing"
+
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+
+func labelsChildToiler(ctx context.Context, blockCh chan struct{}) {
+	<-blockCh
+}
+
+
+func TestLabels(t *testing.T) {
+
+	type labels struct {
+		Group  string
+		Toiler string
+		Run    string
+	}
+
+	labelsCh := make(chan labels, 1)
+	blockCh  := make(chan struct{})
+
+	group := NewGroup(Name("workers"))
+	group.RegisterWith(ContextToilerFunc(func(ctx context.Context){
+		var l labels
+		l.Group,  _ = pprof.Label(ctx, groupLabel)
+		l.Toiler, _ = pprof.Label(ctx, toilerLabel)
+		l.Run,    _ = pprof.Label(ctx, runLabel)
+		labelsCh <- l
+
+		Go(ctx, func(ctx context.Context){
+			labelsChildToiler(ctx, blockCh)
+		})
+
+		<-blockCh
+	}), Name("mailer"))
+
+	doneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(doneCh)
+	}()
+	defer func() {
+		close(blockCh)
+		<-doneCh
+	}()
+
+	if expected, actual := (labels{Group:"workers", Toiler:"mailer", Run:"1"}), <-labelsCh; expected != actual {
+		t.Errorf("Expected labels %#v, but actually got %#v.", expected, actual)
+		return
+	}
+
+	if expected, actual := "mailer", group.Status()[0].Name; expected != actual {
+		t.Errorf("Expected status name %q, but actually got %q.", expected, actual)
+		return
+	}
+
+	var buffer bytes.Buffer
+	ok := eventually(5*time.Second, func() bool {
+		buffer.Reset()
+		group.DumpStacks(&buffer)
+		return strings.Contains(buffer.String(), "labelsChildToiler")
+	})
+	if !ok {
+		t.Errorf("Expected the child goroutine's stack to be dumped (since it has the toiler's labels), but actually got: %q", buffer.String())
+		return
+	}
+}
+
+
+func TestLabelsDefaultNames(t *testing.T) {
+
+	group := NewGroup()
+	group.Register( ToilerFunc(func(){}) )
+
+	if expected, actual := "toil.ToilerFunc", group.Status()[0].Name; expected != actual {
+		t.Errorf("Expected status name %q, but actually got %q.", expected, actual)
+		return
+	}
+}
@@ -0,0 +1,114 @@
+package toil
+
+
+import (
+	"context"
+	"time"
+)
+
+
+// leaderGateRetryInterval is how long a LeaderGate waits before campaigning again,
+// after its Elector's Campaign method returned an error.
+const leaderGateRetryInterval = time.Second
+
+
+// LeaderGate wraps a toiler, so that it only toils while this replica is the leader,
+// as decided by `elector`. This is for toilers that must toil on only one replica
+// (of possibly many) at a time.
+//
+// The returned toiler campaigns (using `elector`) to become the leader, and then makes
+// the wrapped toiler toil. If the leadership is lost, then the wrapped toiler is stopped
+// (by cancelling its context), and the returned toiler campaigns again.
+//
+// NOTE that the wrapped toiler should be a ContextToiler, since otherwise it cannot be
+// stopped when the leadership is lost. (Its Stop method, if it is a Stopper, is not
+// called then, since it will be made to toil again.)
+//
+// If the wrapped toiler returns while this replica is still the leader, then the
+// leadership is resigned, and the returned toiler returns.
+//
+// The returned toiler is also a Stopper, that stops its current runs (i.e., stops them
+// campaigning, and stops the wrapped toiler, by cancelling its context, and by calling
+// its Stop method, if it is a Stopper).
+//
+// Example:
+//
+//	toiler = toil.LeaderGate(toiler, toil.NewFileElector("/var/run/awesome.lock", 0))
+//	
+//	ToilerGroup.Register(toiler)
+func LeaderGate(toiler Toiler, elector Elector) ContextToiler {
+	gate := leaderGate{
+		toiler:toiler,
+		elector:elector,
+	}
+
+	return &gate
+}
+
+
+type leaderGate struct {
+	toiler  Toiler
+	elector Elector
+	runs    runCancels
+}
+
+
+// Toil is part of the Toiler interface.
+func (gate *leaderGate) Toil() {
+	gate.ToilContext(context.Background())
+}
+
+
+// ToilContext is part of the ContextToiler interface.
+func (gate *leaderGate) ToilContext(ctx context.Context) {
+	ctx, end := gate.runs.begin(ctx)
+	defer end()
+
+	for {
+		lease, resign, err := gate.elector.Campaign(ctx)
+		if nil != err {
+			if nil != ctx.Err() {
+				return
+			}
+
+			select {
+			case <-time.After(leaderGateRetryInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		lost := gate.toilWhileLeader(ctx, lease)
+		resign()
+
+		if !lost || nil != ctx.Err() {
+			return
+		}
+	}
+}
+
+
+// toilWhileLeader makes the wrapped toiler toil, until it returns, or `ctx` or `lease`
+// is done. It returns whether the leadership was lost (i.e., whether `lease` is done).
+func (gate *leaderGate) toilWhileLeader(ctx context.Context, lease context.Context) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stopCancelling := context.AfterFunc(lease, cancel)
+	defer stopCancelling()
+
+	toil(ctx, gate.toiler)
+
+	return nil != lease.Err()
+}
+
+
+// Stop is part of the Stopper interface.
+func (gate *leaderGate) Stop() {
+	gate.runs.cancelAll()
+
+	if stopper, ok := gate.toiler.(Stopper); ok {
+		stopper.Stop()
+	}
+}
@@ -0,0 +1,173 @@
+package toil
+
+
+import (
+	"testing"
+
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+
+// manualElector is an Elector whose leadership is granted (and lost) by the test.
+type manualElector struct {
+	grantCh chan context.Context
+}
+
+func (elector *manualElector) Campaign(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	select {
+	case lease := <-elector.grantCh:
+		return lease, func(){}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+
+func TestLeaderGate(t *testing.T) {
+
+	var numStarted int32
+
+	elector := &manualElector{
+		grantCh:make(chan context.Context),
+	}
+
+	toiler := LeaderGate(countingContextToiler(&numStarted), elector)
+
+	doneCh := make(chan struct{})
+	go func() {
+		toiler.Toil()
+		close(doneCh)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	if expected, actual := int32(0), atomic.LoadInt32(&numStarted); expected != actual {
+		t.Fatalf("Expected the toiler to not toil before being the leader (%d), but actually toiled %d times.", expected, actual)
+	}
+
+	lease, lose := context.WithCancel(context.Background())
+	elector.grantCh <- lease
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to toil after becoming the leader, but it did not.")
+	}
+
+	lose()
+
+	lease, lose = context.WithCancel(context.Background())
+	defer lose()
+	elector.grantCh <- lease
+
+	if !eventually(time.Second, func() bool { return 2 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to toil again after becoming the leader again, but it did not.")
+	}
+
+	toiler.(Stopper).Stop()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the toiler to return after being stopped, but it did not.")
+	}
+}
+
+
+func TestLeaderGateReturns(t *testing.T) {
+
+	var numToiled int32
+
+	elector := &manualElector{
+		grantCh:make(chan context.Context, 1),
+	}
+	elector.grantCh <- context.Background()
+
+	toiler := LeaderGate(ToilerFunc(func(){ atomic.AddInt32(&numToiled, 1) }), elector)
+
+	returned, _ := toilWithin(groupOf(toiler), time.Second)
+	if !returned {
+		t.Fatalf("Expected the toiler to return after the wrapped toiler returned, but it did not.")
+	}
+
+	if expected, actual := int32(1), atomic.LoadInt32(&numToiled); expected != actual {
+		t.Errorf("Expected the wrapped toiler to have toiled %d times, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestLeaderGateStopIsPerRun(t *testing.T) {
+
+	wrapped := new(stopCountingToiler)
+
+	elector := &manualElector{
+		grantCh:make(chan context.Context),
+	}
+
+	toiler := LeaderGate(wrapped, elector)
+
+	toilInBackground := func() chan struct{} {
+		doneCh := make(chan struct{})
+		go func() {
+			toiler.Toil()
+			close(doneCh)
+		}()
+		return doneCh
+	}
+
+	doneCh := toilInBackground()
+
+	lease, lose := context.WithCancel(context.Background())
+	elector.grantCh <- lease
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&wrapped.numStarted) }) {
+		t.Fatalf("Expected the wrapped toiler to toil after becoming the leader, but it did not.")
+	}
+
+	// Losing the leadership stops the wrapped toiler's run through its context, and not
+	// with its Stop method.
+	lose()
+
+	lease, lose = context.WithCancel(context.Background())
+	defer lose()
+	elector.grantCh <- lease
+
+	if !eventually(time.Second, func() bool { return 2 == atomic.LoadInt32(&wrapped.numStarted) }) {
+		t.Fatalf("Expected the wrapped toiler to toil again after becoming the leader again, but it did not.")
+	}
+
+	if expected, actual := int32(0), atomic.LoadInt32(&wrapped.numStopped); expected != actual {
+		t.Errorf("Expected the wrapped toiler's Stop method to have been called %d times (by losing the leadership), but actually was %d.", expected, actual)
+	}
+
+	toiler.(Stopper).Stop()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the toiler to return after being stopped, but it did not.")
+	}
+
+	// Stopping cancelled the first run's campaign. A second run (such as after the Group
+	// restarts the toiler) campaigns again, and makes the wrapped toiler toil again once
+	// this replica is the leader (again).
+	doneCh = toilInBackground()
+
+	elector.grantCh <- lease
+
+	if !eventually(time.Second, func() bool { return 3 == atomic.LoadInt32(&wrapped.numStarted) }) {
+		t.Fatalf("Expected the wrapped toiler to toil in a later run, but it did not.")
+	}
+
+	toiler.(Stopper).Stop()
+	<-doneCh
+}
+
+
+// groupOf returns a new Group with only `toiler` registered.
+func groupOf(toiler Toiler) Group {
+	group := NewGroup()
+	group.Register(toiler)
+
+	return group
+}
@@ -0,0 +1,85 @@
+package toil
+
+
+import (
+	"time"
+)
+
+
+// SynchronousNotices returns a GroupOption that makes the Group deliver notices
+// (i.e., calls to a toiler's ReturnedNotice, PanickedNotice, PanickedErrorNotice
+// and RecoveredNotice methods) synchronously.
+//
+// By default, each notice is delivered in its own (detached) goroutine. So a
+// notice may be delivered after the Group's Toil method has returned, and the
+// order notices are delivered in is undefined.
+//
+// With SynchronousNotices, a toiler's notices are delivered, in order, before
+// the toiler is considered finished toiling. So, once the Group's Toil method
+// returns, all the notices have been delivered.
+//
+// If a notice panic()s, then that panic is recovered from (and ignored).
+//
+// If `timeout` is greater than zero, then each notice is given at most `timeout`
+// to return. A notice that takes longer than that is left running (in its own
+// goroutine) and no longer waited on. If `timeout` is zero (or less), then notices
+// are waited on for as long as they take, and are called on the toiler's goroutine.
+func SynchronousNotices(timeout time.Duration) GroupOption {
+	return synchronousNoticesOption{
+		timeout:timeout,
+	}
+}
+
+
+type synchronousNoticesOption struct {
+	timeout time.Duration
+}
+
+
+func (option synchronousNoticesOption) applyGroupOption(config *groupConfig) {
+	config.synchronousNotices = true
+	config.noticeTimeout = option.timeout
+}
+
+
+// notice delivers a notice (i.e., calls fn), either synchronously or asynchronously,
+// depending on the configuration.
+func (config groupConfig) notice(fn func()) {
+
+	// We do the actual call to fn in a goroutine, since we don't want it
+	// to block or panic() here!
+	//
+	// NOTE THAT THIS IS A POTENTIAL SOURCE OF A RESOURCE LEAK!!!!!!
+	if !config.synchronousNotices {
+		go fn()
+		return
+	}
+
+	protected := func() {
+		defer func() {
+			recover()
+		}()
+
+		fn()
+	}
+
+	if config.noticeTimeout <= 0 {
+		protected()
+		return
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+
+		protected()
+	}()
+
+	timerCh, stopTimer := config.clock.NewTimer(config.noticeTimeout)
+	defer stopTimer()
+
+	select {
+	case <-doneCh:
+	case <-timerCh:
+	}
+}
@@ -0,0 +1,116 @@
+package toil
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil/toiltest"
+
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+
+func TestSynchronousNoticesReturned(t *testing.T) {
+
+	const numToilers = 25
+
+	var numReturned int64
+
+	group := NewGroup(SynchronousNotices(0))
+
+	for i:=0; i<numToilers; i++ {
+		toiler := toiltest.NewRecorder()
+		toiler.ToilFunc(func(){
+			go toiler.Terminate()
+		})
+		toiler.ReturnedNoticeFunc(func(){
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&numReturned, 1)
+		})
+
+		group.Register(toiler)
+	}
+
+	group.Toil()
+
+	if expected, actual := int64(numToilers), atomic.LoadInt64(&numReturned); expected != actual {
+		t.Errorf("Expected all %d ReturnedNotice() calls to be delivered when Toil() returned, but actually %d were.", expected, actual)
+		return
+	}
+}
+
+
+func TestSynchronousNoticesOrdered(t *testing.T) {
+
+	var mutex sync.Mutex
+	var notices []string
+
+	toiler := toiltest.NewRecorder()
+	toiler.PanickedNoticeFunc(func(interface{}){
+		mutex.Lock()
+		defer mutex.Unlock()
+		notices = append(notices, "panicked")
+	})
+	toiler.RecoveredNoticeFunc(func(interface{}){
+		mutex.Lock()
+		defer mutex.Unlock()
+		notices = append(notices, "recovered")
+	})
+	toiler.ToilFunc(func(){
+		go toiler.Panic("ordered")
+	})
+
+	group := NewGroup(Isolate, SynchronousNotices(time.Second))
+	group.Register(toiler)
+
+	group.Toil()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if expected, actual := 2, len(notices); expected != actual {
+		t.Errorf("Expected %d notices to be delivered when Toil() returned, but actually %d were: %v", expected, actual, notices)
+		return
+	}
+	if expected, actual := "panicked", notices[0]; expected != actual {
+		t.Errorf("Expected first notice to be %q, but actually was %q.", expected, actual)
+	}
+	if expected, actual := "recovered", notices[1]; expected != actual {
+		t.Errorf("Expected second notice to be %q, but actually was %q.", expected, actual)
+	}
+}
+
+
+func TestSynchronousNoticesProtected(t *testing.T) {
+
+	blockCh := make(chan struct{})
+	defer close(blockCh)
+
+	panickingToiler := toiltest.NewRecorder()
+	panickingToiler.ToilFunc(func(){
+		go panickingToiler.Terminate()
+	})
+	panickingToiler.ReturnedNoticeFunc(func(){
+		panic("notice panic()ed")
+	})
+
+	blockingToiler := toiltest.NewRecorder()
+	blockingToiler.ToilFunc(func(){
+		go blockingToiler.Terminate()
+	})
+	blockingToiler.ReturnedNoticeFunc(func(){
+		<-blockCh
+	})
+
+	group := NewGroup(SynchronousNotices(10 * time.Millisecond))
+	group.Register(panickingToiler)
+	group.Register(blockingToiler)
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+}
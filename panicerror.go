@@ -0,0 +1,39 @@
+package toil
+
+
+import (
+	"fmt"
+	"time"
+)
+
+
+// PanicError is an error that describes a toiler's Toil method panic()ing.
+//
+// Besides the recovered panic value, it also carries the stack trace of the
+// goroutine the toiler was toiling in (captured at the moment the panic was
+// recovered), the toiler that panic()ed, and when the panic was recovered.
+//
+// A toiler can receive a *PanicError by having a PanickedErrorNotice method.
+type PanicError struct {
+	Value  interface{}
+	Stack  []byte
+	Toiler Toiler
+	Time   time.Time
+}
+
+
+// Error is part of the error interface.
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("toil: toiler %T panic()ed: %v", err.Toiler, err.Value)
+}
+
+
+// Unwrap returns the panic value, if the panic value is itself an error.
+// Otherwise it returns nil.
+func (err *PanicError) Unwrap() error {
+	if e, ok := err.Value.(error); ok {
+		return e
+	}
+
+	return nil
+}
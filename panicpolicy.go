@@ -0,0 +1,83 @@
+package toil
+
+
+import (
+	"fmt"
+)
+
+
+type panicPolicyKind int
+
+const (
+	propagatePanicPolicyKind panicPolicyKind = iota
+	isolatePanicPolicyKind
+	stopGroupPanicPolicyKind
+	exitProcessPanicPolicyKind
+)
+
+
+// PanicPolicy decides what a Group does when one of its toilers' Toil method panic()s.
+//
+// A PanicPolicy is also a GroupOption, and is given to a Group by passing it to NewGroup.
+// For example:
+//
+//	group := toil.NewGroup(toil.StopGroup)
+//
+// If no PanicPolicy is given to NewGroup, then Propagate is used.
+type PanicPolicy struct {
+	kind     panicPolicyKind
+	exitCode int
+}
+
+
+var (
+	// Propagate makes the Group's Toil method panic() on the same panic value
+	// that the toiler's Toil method panic()ed on.
+	//
+	// This is the default PanicPolicy.
+	Propagate = PanicPolicy{kind:propagatePanicPolicyKind}
+
+	// Isolate makes the Group recover from the panic, record the panic (see the
+	// Group's Panics method), and keep the rest of its toilers toiling.
+	Isolate = PanicPolicy{kind:isolatePanicPolicyKind}
+
+	// StopGroup makes the Group recover from the panic, record the panic (see the
+	// Group's Panics method), and gracefully stop the rest of its toilers (see the
+	// Group's Stop method).
+	//
+	// The Group's Toil method then returns once the rest of its toilers have
+	// returned, and the Group's Err method returns the (first) *PanicError.
+	StopGroup = PanicPolicy{kind:stopGroupPanicPolicyKind}
+)
+
+
+// ExitProcess returns a PanicPolicy that makes the Group log the panic (including
+// its stack trace) and then exit the process with the exit code `code`, via os.Exit.
+func ExitProcess(code int) PanicPolicy {
+	return PanicPolicy{
+		kind:exitProcessPanicPolicyKind,
+		exitCode:code,
+	}
+}
+
+
+func (policy PanicPolicy) applyGroupOption(config *groupConfig) {
+	config.panicPolicy = policy
+}
+
+
+// String is part of the fmt.Stringer interface.
+func (policy PanicPolicy) String() string {
+	switch policy.kind {
+	case propagatePanicPolicyKind:
+		return "Propagate"
+	case isolatePanicPolicyKind:
+		return "Isolate"
+	case stopGroupPanicPolicyKind:
+		return "StopGroup"
+	case exitProcessPanicPolicyKind:
+		return fmt.Sprintf("ExitProcess(%d)", policy.exitCode)
+	default:
+		return fmt.Sprintf("PanicPolicy(%d)", policy.kind)
+	}
+}
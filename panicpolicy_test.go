@@ -0,0 +1,322 @@
+package toil
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil/toiltest"
+
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+
+type stoppableToiler struct {
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newStoppableToiler() *stoppableToiler {
+	toiler := stoppableToiler{
+		stopCh:make(chan struct{}),
+	}
+
+	return &toiler
+}
+
+func (toiler *stoppableToiler) Toil() {
+	<-toiler.stopCh
+}
+
+func (toiler *stoppableToiler) Stop() {
+	toiler.stopOnce.Do(func(){
+		close(toiler.stopCh)
+	})
+}
+
+
+// toilWithin calls group.Toil() and reports whether it returned (rather than
+// panic()ed or blocked) within the timeout.
+func toilWithin(group Group, timeout time.Duration) (returned bool, panicValue interface{}) {
+	type result struct {
+		returned   bool
+		panicValue interface{}
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		defer func() {
+			if panicValue := recover(); nil != panicValue {
+				resultCh <- result{panicValue:panicValue}
+			}
+		}()
+
+		group.Toil()
+		resultCh <- result{returned:true}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.returned, r.panicValue
+	case <-time.After(timeout):
+		return false, nil
+	}
+}
+
+
+func TestPanicPolicyString(t *testing.T) {
+
+	tests := []struct{
+		Policy   PanicPolicy
+		Expected string
+	}{
+		{
+			Policy:   Propagate,
+			Expected: "Propagate",
+		},
+		{
+			Policy:   Isolate,
+			Expected: "Isolate",
+		},
+		{
+			Policy:   StopGroup,
+			Expected: "StopGroup",
+		},
+		{
+			Policy:   ExitProcess(3),
+			Expected: "ExitProcess(3)",
+		},
+	}
+
+	for testNumber, test := range tests {
+		if expected, actual := test.Expected, test.Policy.String(); expected != actual {
+			t.Errorf("For test #%d, expected %q, but actually got %q.", testNumber, expected, actual)
+			continue
+		}
+	}
+}
+
+
+func TestToilPanicPolicyPropagate(t *testing.T) {
+
+	group := NewGroup(Propagate)
+
+	group.Register( ToilerFunc(func(){ panic("propagated") }) )
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if returned {
+		t.Errorf("Expected Toil() to panic(), but it returned.")
+		return
+	}
+	if expected, actual := "propagated", panicValue; expected != actual {
+		t.Errorf("Expected caught panic value to be [%v], but actually was [%v].", expected, actual)
+		return
+	}
+}
+
+
+func TestToilPanicPolicyIsolate(t *testing.T) {
+
+	recorder := toiltest.NewRecorder()
+
+	group := NewGroup(Isolate)
+
+	group.Register(recorder)
+	group.Register( ToilerFunc(func(){ panic("isolated") }) )
+
+	go func() {
+		// Wait for the panic to be recorded, while the recorder keeps toiling,
+		// and then make the recorder return.
+		for 0 == len(group.Panics()) {
+			time.Sleep(time.Millisecond)
+		}
+		recorder.Terminate()
+	}()
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	panics := group.Panics()
+	if expected, actual := 1, len(panics); expected != actual {
+		t.Errorf("Expected number of recorded panics to be %d, but actually was %d.", expected, actual)
+		return
+	}
+	if expected, actual := "isolated", panics[0].Value; expected != actual {
+		t.Errorf("Expected recorded panic value to be [%v], but actually was [%v].", expected, actual)
+		return
+	}
+
+	if err := group.Err(); nil != err {
+		t.Errorf("Expected error to be nil, but actually was: %v", err)
+		return
+	}
+}
+
+
+func TestToilPanicPolicyStopGroup(t *testing.T) {
+
+	stoppable := newStoppableToiler()
+
+	group := NewGroup(StopGroup)
+
+	group.Register(stoppable)
+	group.Register( ToilerFunc(func(){ panic("stopped") }) )
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	err := group.Err()
+	panicError, ok := err.(*PanicError)
+	if !ok {
+		t.Errorf("Expected error to be a *PanicError, but actually was: (%T) %v", err, err)
+		return
+	}
+	if expected, actual := "stopped", panicError.Value; expected != actual {
+		t.Errorf("Expected panic value to be [%v], but actually was [%v].", expected, actual)
+		return
+	}
+}
+
+
+func TestToilPanicPolicyExitProcess(t *testing.T) {
+
+	exitCodeCh := make(chan int, 1)
+
+	oldOSExit := osExit
+	osExit = func(code int) {
+		exitCodeCh <- code
+	}
+	defer func() {
+		osExit = oldOSExit
+	}()
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	group := NewGroup(ExitProcess(7))
+
+	group.Register( ToilerFunc(func(){ panic("exited") }) )
+
+	go group.Toil()
+
+	select {
+	case code := <-exitCodeCh:
+		if expected, actual := 7, code; expected != actual {
+			t.Errorf("Expected exit code to be %d, but actually was %d.", expected, actual)
+			return
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected the process to (pretend to) exit, but it did not.")
+		return
+	}
+}
+
+
+func TestStop(t *testing.T) {
+
+	group := NewGroup()
+
+	for i:=0; i<5; i++ {
+		group.Register( newStoppableToiler() )
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		group.Stop()
+	}()
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return after Stop(), but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+}
+
+
+func TestToilPanicPolicyRecoveredNotice(t *testing.T) {
+
+	tests := []struct{
+		Policy            PanicPolicy
+		ExpectedRecovered bool
+	}{
+		{
+			Policy:            Isolate,
+			ExpectedRecovered: true,
+		},
+		{
+			Policy:            StopGroup,
+			ExpectedRecovered: true,
+		},
+		{
+			Policy:            Propagate,
+			ExpectedRecovered: false,
+		},
+	}
+
+	for testNumber, test := range tests {
+
+		recoveredCh := make(chan interface{}, 1)
+		panickedCh  := make(chan interface{}, 1)
+
+		toiler := toiltest.NewRecorder()
+		toiler.RecoveredNoticeFunc(func(panicValue interface{}){
+			recoveredCh <- panicValue
+		})
+		toiler.PanickedNoticeFunc(func(panicValue interface{}){
+			panickedCh <- panicValue
+		})
+
+		group := NewGroup(test.Policy)
+		group.Register(toiler)
+
+		go func() {
+			defer func() {
+				recover()
+			}()
+
+			group.Toil()
+		}()
+
+		toiler.Panic(testNumber)
+
+		select {
+		case panicValue := <-panickedCh:
+			if expected, actual := interface{}(testNumber), panicValue; expected != actual {
+				t.Errorf("For test #%d, expected PanickedNotice() panic value to be [%v], but actually was [%v].", testNumber, expected, actual)
+				continue
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("For test #%d, expected PanickedNotice() to be called, but it was not.", testNumber)
+			continue
+		}
+
+		if !test.ExpectedRecovered {
+			select {
+			case panicValue := <-recoveredCh:
+				t.Errorf("For test #%d, expected RecoveredNotice() to not be called, but it was with: %v", testNumber, panicValue)
+			case <-time.After(20 * time.Millisecond):
+			}
+			continue
+		}
+
+		select {
+		case panicValue := <-recoveredCh:
+			if expected, actual := interface{}(testNumber), panicValue; expected != actual {
+				t.Errorf("For test #%d, expected RecoveredNotice() panic value to be [%v], but actually was [%v].", testNumber, expected, actual)
+				continue
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("For test #%d, expected RecoveredNotice() to be called, but it was not.", testNumber)
+			continue
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package toil
+
+
+// Registration is a handle to a toiler registered with a Group. (See the Group's
+// RegisterWith method.)
+type Registration interface {
+
+	// Pause stops the toiler toiling (without unregistering it), and keeps it from
+	// toiling, until Resume is called.
+	//
+	// The toiler is stopped by cancelling its run's context (if it is a ContextToiler).
+	// A toiler that is not a ContextToiler cannot be interrupted, so it is paused once
+	// its current run returns.
+	//
+	// NOTE that the toiler's Stop method (if it is a Stopper) is not called, since that
+	// is for when the Group is stopped (for good).
+	//
+	// While a toiler is paused, the Group's Toil method does not return. (Unless
+	// the Group is stopped.)
+	Pause()
+
+	// Resume makes a paused toiler toil again.
+	Resume()
+
+	// Status returns the status of the toiler.
+	Status() ToilerStatus
+}
+
+
+type internalRegistration struct {
+	daemon       *internalGroupDaemon
+	registration *registeredToiler
+}
+
+
+func (handle internalRegistration) Pause() {
+	handle.daemon.pause(handle.registration)
+}
+
+
+func (handle internalRegistration) Resume() {
+	handle.daemon.resume(handle.registration)
+}
+
+
+func (handle internalRegistration) Status() ToilerStatus {
+	status := handle.registration.status()
+
+	if scheduler := handle.daemon.schedulerOf(handle.registration); nil != scheduler {
+		status.QueuePosition = scheduler.positions()[handle.registration]
+	}
+
+	return status
+}
+
+
+// pause pauses a registered toiler. (See the Pause method of Registration.)
+func (daemon *internalGroupDaemon) pause(registration *registeredToiler) {
+	paused, run := registration.pause()
+	if !paused {
+		return
+	}
+
+	daemon.emit(EventPaused, registration, 0, nil)
+
+	if nil != run {
+		run.cancel()
+	}
+}
+
+
+// resume resumes a paused registered toiler. (See the Resume method of Registration.)
+func (daemon *internalGroupDaemon) resume(registration *registeredToiler) {
+	if !registration.resume() {
+		return
+	}
+
+	daemon.emit(EventResumed, registration, 0, nil)
+}
+
+
+// park keeps a toiler's run (that was paused) from finishing, until the toiler
+// is resumed (and then makes the toiler toil again) or the daemon is stopped.
+//
+// Keeping the run from finishing is what keeps the Group's Toil method from returning.
+//
+// NOTE that park must be called before the run is released.
+func (daemon *internalGroupDaemon) park(run *toilRun) {
+
+	registration := run.registration
+
+	// A parked run does not take up a spot in the pool's concurrency limit.
+	run.releaseSlot()
+
+	select {
+	case <-registration.resumed():
+		daemon.respawn(registration)
+	case <-daemon.stoppedCh:
+	}
+}
@@ -0,0 +1,300 @@
+package toil
+
+
+import (
+	"testing"
+
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+
+// countingContextToiler returns a ContextToiler that counts how many times it
+// started toiling, and that toils until its context is done.
+func countingContextToiler(numStarted *int32) ContextToiler {
+	return ContextToilerFunc(func(ctx context.Context) {
+		atomic.AddInt32(numStarted, 1)
+		<-ctx.Done()
+	})
+}
+
+
+func TestRegistrationPauseResume(t *testing.T) {
+
+	var numStarted int32
+
+	group := NewGroup()
+	defer group.Stop()
+
+	registration := group.RegisterWith(countingContextToiler(&numStarted))
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	registration.Pause()
+
+	if !eventually(time.Second, func() bool { return !registration.Status().Toiling }) {
+		t.Fatalf("Expected the paused toiler to not be toiling, but it was.")
+	}
+
+	if expected, actual := true, registration.Status().Paused; expected != actual {
+		t.Errorf("Expected the status to say paused was %t, but actually was %t.", expected, actual)
+	}
+
+	if expected, actual := 1, group.Len(); expected != actual {
+		t.Errorf("Expected the paused toiler to still be registered, and the length to be %d, but actually was %d.", expected, actual)
+	}
+
+	registration.Resume()
+
+	if !eventually(time.Second, func() bool { return 2 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the resumed toiler to have started toiling again, but it did not.")
+	}
+
+	status := registration.Status()
+
+	if expected, actual := false, status.Paused; expected != actual {
+		t.Errorf("Expected the status to say paused was %t, but actually was %t.", expected, actual)
+	}
+
+	if expected, actual := 2, status.Runs; expected != actual {
+		t.Errorf("Expected the number of runs to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+// stopCountingToiler is a ContextToiler (that toils until its context is done) and a
+// Stopper, that counts how many times it started toiling, and was stopped.
+type stopCountingToiler struct {
+	numStarted int32
+	numStopped int32
+}
+
+
+func (toiler *stopCountingToiler) Toil() {
+	toiler.ToilContext(context.Background())
+}
+
+
+func (toiler *stopCountingToiler) ToilContext(ctx context.Context) {
+	atomic.AddInt32(&toiler.numStarted, 1)
+	<-ctx.Done()
+}
+
+
+func (toiler *stopCountingToiler) Stop() {
+	atomic.AddInt32(&toiler.numStopped, 1)
+}
+
+
+func TestPauseDoesNotCallStop(t *testing.T) {
+
+	toiler := new(stopCountingToiler)
+
+	group := NewGroup()
+
+	registration := group.RegisterWith(toiler)
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&toiler.numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	registration.Pause()
+
+	if !eventually(time.Second, func() bool { return !registration.Status().Toiling }) {
+		t.Fatalf("Expected the paused toiler to not be toiling, but it was.")
+	}
+
+	registration.Resume()
+
+	if !eventually(time.Second, func() bool { return 2 == atomic.LoadInt32(&toiler.numStarted) }) {
+		t.Fatalf("Expected the resumed toiler to have started toiling again, but it did not.")
+	}
+
+	if expected, actual := int32(0), atomic.LoadInt32(&toiler.numStopped); expected != actual {
+		t.Errorf("Expected the toiler's Stop method to have been called %d times (by pausing it), but actually was %d.", expected, actual)
+	}
+
+	group.Stop()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&toiler.numStopped) }) {
+		t.Errorf("Expected the toiler's Stop method to have been called (by stopping the Group), but it was not.")
+	}
+}
+
+
+func TestPauseKeepsToilFromReturning(t *testing.T) {
+
+	var numStarted int32
+
+	group := NewGroup()
+
+	registration := group.RegisterWith(countingContextToiler(&numStarted))
+
+	doneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(doneCh)
+	}()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	registration.Pause()
+
+	select {
+	case <-doneCh:
+		t.Fatalf("Expected Toil() to not return while a toiler is paused, but it did.")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	group.Stop()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Toil() to return after the group was stopped, but it did not.")
+	}
+}
+
+
+func TestGroupPauseResume(t *testing.T) {
+
+	var numStarted1 int32
+	var numStarted2 int32
+	var numStarted3 int32
+
+	group := NewGroup()
+	defer group.Stop()
+
+	group.Register(countingContextToiler(&numStarted1))
+	group.Register(countingContextToiler(&numStarted2))
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted1) && 1 == atomic.LoadInt32(&numStarted2) }) {
+		t.Fatalf("Expected the toilers to have started toiling, but they did not.")
+	}
+
+	group.Pause()
+
+	// A toiler registered while the group is paused starts off paused.
+	registration := group.RegisterWith(countingContextToiler(&numStarted3))
+
+	if !eventually(time.Second, func() bool {
+		for _,status := range group.Status() {
+			if status.Toiling || !status.Paused {
+				return false
+			}
+		}
+		return true
+	}) {
+		t.Fatalf("Expected all the toilers to be paused, but they were not: %#v", group.Status())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if expected, actual := int32(0), atomic.LoadInt32(&numStarted3); expected != actual {
+		t.Errorf("Expected the toiler registered while paused to have started %d times, but actually was %d.", expected, actual)
+	}
+
+	group.Resume()
+
+	if !eventually(time.Second, func() bool {
+		return 2 == atomic.LoadInt32(&numStarted1) && 2 == atomic.LoadInt32(&numStarted2) && 1 == atomic.LoadInt32(&numStarted3)
+	}) {
+		t.Fatalf("Expected the toilers to have started toiling again, but they did not.")
+	}
+
+	if expected, actual := false, registration.Status().Paused; expected != actual {
+		t.Errorf("Expected the status to say paused was %t, but actually was %t.", expected, actual)
+	}
+}
+
+
+func TestEvents(t *testing.T) {
+
+	var numStarted int32
+
+	eventCh := make(chan Event, 16)
+
+	group := NewGroup(Name("workers"), Events(eventCh))
+	defer group.Stop()
+
+	registration := group.RegisterWith(countingContextToiler(&numStarted), Name("worker"))
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	registration.Pause()
+	registration.Resume()
+
+	if !eventually(time.Second, func() bool { return 2 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the resumed toiler to have started toiling again, but it did not.")
+	}
+
+	expected := []EventKind{EventStarted, EventPaused, EventResumed, EventStarted}
+
+	for i, expectedKind := range expected {
+		var event Event
+		select {
+		case event = <-eventCh:
+		case <-time.After(time.Second):
+			t.Fatalf("For event #%d, expected an event, but did not get one.", i)
+		}
+
+		if actual := event.Kind; expectedKind != actual {
+			t.Errorf("For event #%d, expected the kind to be %v, but actually was %v.", i, expectedKind, actual)
+		}
+
+		if expected, actual := "workers", event.Group; expected != actual {
+			t.Errorf("For event #%d, expected the group to be %q, but actually was %q.", i, expected, actual)
+		}
+
+		if expected, actual := "worker", event.Toiler; expected != actual {
+			t.Errorf("For event #%d, expected the toiler to be %q, but actually was %q.", i, expected, actual)
+		}
+	}
+}
+
+
+func TestEventKindString(t *testing.T) {
+
+	tests := []struct{
+		Kind     EventKind
+		Expected string
+	}{
+		{
+			Kind:     EventStarted,
+			Expected: "started",
+		},
+		{
+			Kind:     EventPanicked,
+			Expected: "panicked",
+		},
+		{
+			Kind:     EventResumed,
+			Expected: "resumed",
+		},
+		{
+			Kind:     EventKind(99),
+			Expected: "EventKind(99)",
+		},
+	}
+
+	for testNumber, test := range tests {
+		if expected, actual := test.Expected, test.Kind.String(); expected != actual {
+			t.Errorf("For test #%d, expected %q, but actually was %q.", testNumber, expected, actual)
+		}
+	}
+}
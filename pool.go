@@ -0,0 +1,103 @@
+package toil
+
+
+// Pool returns a GroupOption that partitions off a named pool of the Group, with its
+// own MaxConcurrency and RestartPolicy. For example:
+//
+//	group := toil.NewGroup(
+//		toil.Pool("io",  toil.MaxConcurrency(32), toil.RestartOnPanic),
+//		toil.Pool("cpu", toil.MaxConcurrency(runtime.NumCPU())),
+//	)
+//
+// Toilers are registered into a pool with InPool. For example:
+//
+//	group.RegisterWith(toiler, toil.InPool("io"))
+//
+// The pool options are the same GroupOptions given to NewGroup, but only MaxConcurrency,
+// RestartPolicy and RestartBackoff options apply to a pool. (Everything else is shared by
+// the whole Group.)
+//
+// Toilers not registered into a pool are in the Group's default pool, which gets its
+// MaxConcurrency, RestartPolicy and RestartBackoff from the options given to NewGroup.
+//
+// All the pools are toiled (and stopped) together, by the Group's Toil (and Stop) method,
+// and the Group's Status includes the toilers of all the pools.
+func Pool(name string, options ...GroupOption) GroupOption {
+	return poolOption{
+		name:name,
+		options:options,
+	}
+}
+
+
+type poolOption struct {
+	name    string
+	options []GroupOption
+}
+
+
+func (option poolOption) applyGroupOption(config *groupConfig) {
+	if nil == config.pools {
+		config.pools = map[string]groupConfig{}
+	}
+
+	config.pools[option.name] = newGroupConfig(option.options...)
+}
+
+
+// InPool returns a RegisterOption that registers a toiler into the named pool. (See Pool.)
+//
+// If the Group does not have a pool with that name, then one is made, with the same
+// MaxConcurrency, RestartPolicy and RestartBackoff as the Group's default pool. (But with
+// its own limit on how many of its toilers can be toiling at the same time.)
+func InPool(name string) RegisterOption {
+	return inPoolOption{
+		name:name,
+	}
+}
+
+
+type inPoolOption struct {
+	name string
+}
+
+
+func (option inPoolOption) applyRegisterOption(config *registerConfig) {
+	config.pool = option.name
+}
+
+
+// toilerPool is a pool of a group's toilers.
+type toilerPool struct {
+	name           string
+	scheduler      *scheduler // NOTE that this is nil if the pool does not have a MaxConcurrency.
+	restartPolicy  RestartPolicy
+	restartBackoff restartBackoffOption
+}
+
+
+func newToilerPool(name string, config groupConfig, stoppedCh <-chan struct{}) *toilerPool {
+	pool := toilerPool{
+		name:name,
+		scheduler:newScheduler(config.maxConcurrency, stoppedCh),
+		restartPolicy:config.restartPolicy,
+		restartBackoff:config.restartBackoff,
+	}
+
+	return &pool
+}
+
+
+// pool returns the daemon's pool with the name, making it (with the Group's own
+// configuration) if it does not exist yet.
+//
+// NOTE that this must only be called from the daemon's goroutine.
+func (daemon *internalGroupDaemon) pool(name string) *toilerPool {
+	pool, ok := daemon.pools[name]
+	if !ok {
+		pool = newToilerPool(name, daemon.config, daemon.stoppedCh)
+		daemon.pools[name] = pool
+	}
+
+	return pool
+}
@@ -0,0 +1,206 @@
+package toil
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil/toiltest"
+
+	"sync/atomic"
+	"time"
+)
+
+
+func TestPool(t *testing.T) {
+
+	blockCh := make(chan struct{})
+
+	group := NewGroup(
+		Pool("a", MaxConcurrency(1)),
+		Pool("b", MaxConcurrency(1)),
+	)
+
+	group.RegisterWith( ToilerFunc(func(){ <-blockCh }), InPool("a") )
+	group.RegisterWith( ToilerFunc(func(){ <-blockCh }), InPool("b") )
+	group.RegisterWith( ToilerFunc(func(){ <-blockCh }), InPool("a") )
+	group.Register( ToilerFunc(func(){ <-blockCh }) )
+
+	doneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(doneCh)
+	}()
+	defer func() {
+		close(blockCh)
+		<-doneCh
+	}()
+
+	ok := eventually(5*time.Second, func() bool {
+		statuses := group.Status()
+		return (statuses[0].Toiling || statuses[2].Toiling) && statuses[1].Toiling && statuses[3].Toiling
+	})
+	if !ok {
+		t.Errorf("Expected one toiler in each pool to be toiling, but actually got: %#v", group.Status())
+		return
+	}
+
+	statuses := group.Status()
+
+	for i,expected := range []string{"a", "b", "a", ""} {
+		if actual := statuses[i].Pool; expected != actual {
+			t.Errorf("For toiler #%d, expected pool %q, but actually got %q.", i, expected, actual)
+		}
+	}
+
+	if statuses[0].Toiling == statuses[2].Toiling {
+		t.Errorf("Expected only one of the toilers in pool \"a\" to be toiling, but actually got: %#v", statuses)
+	}
+	queued := statuses[0]
+	if statuses[0].Toiling {
+		queued = statuses[2]
+	}
+	if expected, actual := 1, queued.QueuePosition; expected != actual {
+		t.Errorf("Expected the queued toiler's queue position to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestRestartOnPanic(t *testing.T) {
+
+	var numRuns int64
+
+	group := NewGroup(RestartOnPanic)
+	group.Register( ToilerFunc(func(){
+		if atomic.AddInt64(&numRuns, 1) <= 2 {
+			panic("restart me")
+		}
+	}) )
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	status := group.Status()[0]
+	if expected, actual := 3, status.Runs; expected != actual {
+		t.Errorf("Expected number of runs to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 2, status.Restarts; expected != actual {
+		t.Errorf("Expected number of restarts to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 2, status.Panics; expected != actual {
+		t.Errorf("Expected number of panics to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestRestartAlwaysInPool(t *testing.T) {
+
+	var numRuns int64
+
+	group := NewGroup(Pool("forever", RestartAlways))
+
+	group.RegisterWith( ToilerFunc(func(){
+		if 5 == atomic.AddInt64(&numRuns, 1) {
+			go group.Stop()
+		}
+	}), InPool("forever") )
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	if minimum, actual := int64(5), atomic.LoadInt64(&numRuns); actual < minimum {
+		t.Errorf("Expected number of runs to be at least %d, but actually was %d.", minimum, actual)
+	}
+}
+
+
+func TestRestartPolicyString(t *testing.T) {
+
+	tests := []struct{
+		Policy   RestartPolicy
+		Expected string
+	}{
+		{
+			Policy:   NeverRestart,
+			Expected: "NeverRestart",
+		},
+		{
+			Policy:   RestartOnPanic,
+			Expected: "RestartOnPanic",
+		},
+		{
+			Policy:   RestartAlways,
+			Expected: "RestartAlways",
+		},
+	}
+
+	for testNumber, test := range tests {
+		if expected, actual := test.Expected, test.Policy.String(); expected != actual {
+			t.Errorf("For test #%d, expected %q, but actually got %q.", testNumber, expected, actual)
+			continue
+		}
+	}
+}
+
+
+func TestRestartBackoff(t *testing.T) {
+
+	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var numRuns int64
+
+	group := NewGroup(Isolate, RestartOnPanic, RestartBackoff(time.Second, 4*time.Second), WithClock(clock))
+	group.Register( ToilerFunc(func(){
+		atomic.AddInt64(&numRuns, 1)
+		panic("restart me")
+	}) )
+
+	go group.Toil()
+	defer group.Stop()
+
+	for i,expected := range []time.Duration{time.Second, 2*time.Second, 4*time.Second, 4*time.Second} {
+		clock.BlockUntilWaiters(1)
+
+		if actual := clock.Deadlines()[0].Sub(clock.Now()); expected != actual {
+			t.Errorf("For restart #%d, expected the backoff to be %v, but actually was %v.", i+1, expected, actual)
+		}
+
+		if expected, actual := int64(i+1), atomic.LoadInt64(&numRuns); expected != actual {
+			t.Errorf("For restart #%d, expected the number of runs (before the backoff is over) to be %d, but actually was %d.", i+1, expected, actual)
+		}
+
+		clock.Advance(expected)
+
+		if !eventually(5*time.Second, func() bool { return int64(i+2) == atomic.LoadInt64(&numRuns) }) {
+			t.Fatalf("For restart #%d, expected the toiler to be restarted after the backoff, but it was not.", i+1)
+		}
+	}
+}
+
+
+func TestInUnknownPoolInheritsGroupConfig(t *testing.T) {
+
+	var numRuns int64
+
+	group := NewGroup(RestartOnPanic, RestartBackoff(0, 0))
+	group.RegisterWith( ToilerFunc(func(){
+		if 1 == atomic.AddInt64(&numRuns, 1) {
+			panic("restart me")
+		}
+	}), InPool("typo") )
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	if expected, actual := 1, group.Status()[0].Restarts; expected != actual {
+		t.Errorf("Expected number of restarts to be %d, but actually was %d.", expected, actual)
+	}
+}
@@ -0,0 +1,53 @@
+package toil
+
+
+// MaxConcurrency returns a GroupOption that limits how many of the Group's toilers
+// may be toiling at the same time to `max`.
+//
+// Toilers that are waiting to start toiling are queued up by their priority. (See
+// Priority.) And a toiler's position in that queue is shown in the Group's Status.
+func MaxConcurrency(max int) GroupOption {
+	return maxConcurrencyOption{
+		max:max,
+	}
+}
+
+
+type maxConcurrencyOption struct {
+	max int
+}
+
+
+func (option maxConcurrencyOption) applyGroupOption(config *groupConfig) {
+	config.maxConcurrency = option.max
+}
+
+
+// Priority returns a RegisterOption that gives a toiler a priority.
+//
+// The priority only matters when the Group has a MaxConcurrency, and toilers are
+// waiting to start toiling. Then, toilers with a higher priority are started first,
+// but toilers with a lower priority are still started (less often), so they are not
+// starved. (The weight of priority 0 is 1, and each priority above that adds 1 to
+// the weight. So, for example, priority 2 toilers are started 3 times as often as
+// priority 0 toilers.)
+//
+// Priorities above 1048575 are treated as 1048575, and priorities below -1048575 are
+// treated as -1048575.
+//
+// The default priority is 0.
+func Priority(priority int) RegisterOption {
+	return priorityOption{
+		priority:priority,
+	}
+}
+
+
+type priorityOption struct {
+	priority int
+}
+
+
+func (option priorityOption) applyRegisterOption(config *registerConfig) {
+	config.priority = option.priority
+}
@@ -0,0 +1,171 @@
+package toil
+
+
+import (
+	"testing"
+
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+
+func TestMaxConcurrency(t *testing.T) {
+
+	const max = 3
+
+	var numToiling int64
+	var maxToiling int64
+
+	group := NewGroup(MaxConcurrency(max))
+
+	for i:=0; i<20; i++ {
+		group.Register( ToilerFunc(func(){
+			n := atomic.AddInt64(&numToiling, 1)
+			defer atomic.AddInt64(&numToiling, -1)
+
+			for {
+				m := atomic.LoadInt64(&maxToiling)
+				if n <= m || atomic.CompareAndSwapInt64(&maxToiling, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(2 * time.Millisecond)
+		}) )
+	}
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	if maximum, actual := int64(max), atomic.LoadInt64(&maxToiling); maximum < actual {
+		t.Errorf("Expected at most %d toilers to be toiling at the same time, but actually %d were.", maximum, actual)
+	}
+
+	for _,status := range group.Status() {
+		if expected, actual := 1, status.Runs; expected != actual {
+			t.Errorf("Expected each toiler to have toiled %d time(s), but actually toiled %d time(s).", expected, actual)
+			return
+		}
+	}
+}
+
+
+func TestPriorityQueues(t *testing.T) {
+
+	queues := newPriorityQueues()
+
+	low  := &registeredToiler{config:registerConfig{name:"low"}}
+	high := &registeredToiler{config:registerConfig{name:"high", priority:2}}
+
+	for i:=0; i<4; i++ {
+		queues.push(schedulerWaiter{registration:low})
+	}
+	for i:=0; i<4; i++ {
+		queues.push(schedulerWaiter{registration:high})
+	}
+
+	var order []string
+	for 0 < queues.length {
+		order = append(order, queues.pop().registration.name())
+	}
+
+	// Priority 2 has 3 times the weight of priority 0. So the high priority toilers
+	// go first, and get 3 turns for each of the low priority toilers' turns. But the
+	// low priority toilers still get turns (i.e., they are not starved).
+	expected := []string{"high", "low", "high", "high", "high", "low", "low", "low"}
+
+	if len(expected) != len(order) {
+		t.Errorf("Expected order %v, but actually got %v.", expected, order)
+		return
+	}
+	for i := range expected {
+		if expected[i] != order[i] {
+			t.Errorf("Expected order %v, but actually got %v.", expected, order)
+			return
+		}
+	}
+}
+
+
+func TestStrideExtremePriorities(t *testing.T) {
+
+	priorities := []int{math.MinInt, math.MinInt32, -(1<<20), -1, 0, 1, 1<<20, 1<<21, math.MaxInt32, math.MaxInt}
+
+	for testNumber, priority := range priorities {
+		if actual := stride(priority); 0 == actual {
+			t.Errorf("For test #%d, expected the stride of priority %d to not be 0, but it was.", testNumber, priority)
+		}
+	}
+
+	for i:=1; i<len(priorities); i++ {
+		if higher, lower := stride(priorities[i]), stride(priorities[i-1]); lower < higher {
+			t.Errorf("Expected the stride of priority %d (%d) to not be more than the stride of priority %d (%d), but it was.", priorities[i], higher, priorities[i-1], lower)
+		}
+	}
+}
+
+
+func TestPriorityQueuePosition(t *testing.T) {
+
+	blockCh := make(chan struct{})
+
+	var mutex sync.Mutex
+	var order []string
+
+	record := func(name string) Toiler {
+		return ToilerFunc(func(){
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			order = append(order, name)
+		})
+	}
+
+	group := NewGroup(MaxConcurrency(1))
+
+	group.RegisterWith( ToilerFunc(func(){ <-blockCh }), Priority(100) )
+	group.RegisterWith( record("low"), Priority(0) )
+	group.RegisterWith( record("high"), Priority(5) )
+
+	doneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(doneCh)
+	}()
+
+	ok := eventually(5*time.Second, func() bool {
+		statuses := group.Status()
+		return statuses[0].Toiling && 0 != statuses[1].QueuePosition && 0 != statuses[2].QueuePosition
+	})
+	if !ok {
+		t.Errorf("Expected the blocking toiler to be toiling and the others to be queued, but actually got: %#v", group.Status())
+		close(blockCh)
+		return
+	}
+
+	statuses := group.Status()
+	if expected, actual := 0, statuses[0].QueuePosition; expected != actual {
+		t.Errorf("Expected the blocking toiler's queue position to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 2, statuses[1].QueuePosition; expected != actual {
+		t.Errorf("Expected the low priority toiler's queue position to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 1, statuses[2].QueuePosition; expected != actual {
+		t.Errorf("Expected the high priority toiler's queue position to be %d, but actually was %d.", expected, actual)
+	}
+
+	close(blockCh)
+	<-doneCh
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if 2 != len(order) || "high" != order[0] || "low" != order[1] {
+		t.Errorf("Expected the high priority toiler to toil before the low priority toiler, but actually got: %v", order)
+	}
+}
@@ -0,0 +1,182 @@
+package toil
+
+
+import (
+	"context"
+	"errors"
+)
+
+
+// ErrQueueClosed is returned by the methods of a Queue that has been closed.
+var ErrQueueClosed = errors.New("toil: queue closed")
+
+
+// ErrStaleReceipt is returned by the Ack and Nack methods of a Queue when the receipt
+// is not that of a current delivery. (See Queue.)
+var ErrStaleReceipt = errors.New("toil: stale receipt")
+
+
+// Task is a unit of work (i.e., a job) in a Queue.
+type Task struct {
+	// ID identifies the task in its Queue.
+	ID string
+
+	// Payload is what was enqueued.
+	Payload []byte
+
+	// Attempts is the number of times the task has been delivered (including this
+	// time).
+	Attempts int
+
+	// Receipt identifies this delivery of the task. It is what is passed to Ack and
+	// Nack.
+	Receipt string
+}
+
+
+// Queue is an interface that wraps the Enqueue, Dequeue, Ack and Nack methods.
+//
+// A Queue delivers each of its tasks at-least-once. I.e., a task that was delivered
+// (by Dequeue) is delivered again, unless it is acknowledged (by Ack) within the
+// Queue's visibility timeout, or before the process crashes.
+//
+// Enqueue adds a task (with `payload`) to the queue, and returns its ID.
+//
+// Dequeue delivers the next task. If there is no task to deliver, then it blocks until
+// there is, or until `ctx` is done (in which case it returns the context's error).
+//
+// Ack acknowledges that the task delivered with the receipt `receipt` was done, so that
+// it is not delivered again.
+//
+// Nack tells the queue that the task delivered with the receipt `receipt` was not done
+// (such as because doing it panic()ed), so that it is delivered again. Unless it has been
+// delivered too many times already, in which case it is dead-lettered (i.e., set aside,
+// and not delivered again).
+//
+// Each delivery of a task has its own receipt. If the receipt is not that of a current
+// delivery (for example, because the delivery's visibility timeout ran out, and the task
+// was delivered again, to someone else), then Ack and Nack do nothing, and return
+// ErrStaleReceipt. So that a late Ack or Nack does not affect the later delivery.
+//
+// Tasks are done by toilers made with ConsumeQueue. (See NewFileQueue.)
+type Queue interface {
+	Enqueue(payload []byte) (id string, err error)
+	Dequeue(ctx context.Context) (Task, error)
+	Ack(receipt string) error
+	Nack(receipt string) error
+}
+
+
+// TaskHandler does a task (that was delivered by a Queue). (See ConsumeQueue.)
+type TaskHandler func(ctx context.Context, task Task) error
+
+
+// ConsumeQueue returns a toiler that (repeatedly) dequeues tasks from `queue`, and does
+// them with `handler`.
+//
+// If `handler` returns nil, then the task is acknowledged (see Ack). If `handler`
+// returns an error, or panic()s, then the task is negatively acknowledged (see Nack),
+// so that it is delivered again (or, after too many attempts, dead-lettered).
+//
+// NOTE that a panic() in `handler` is not recovered from by the returned toiler. It is
+// left to the Group it is registered with. So, the Group's PanicPolicy and RestartPolicy
+// apply to it, just like with any other toiler. For example:
+//
+//	var (
+//		ToilerGroup = toil.NewGroup(
+//			toil.Pool("jobs", toil.MaxConcurrency(8), toil.RestartOnPanic),
+//		)
+//	)
+//	
+//	// ...
+//	
+//	for i:=0; i<8; i++ {
+//		ToilerGroup.RegisterWith(toil.ConsumeQueue(queue, handler), toil.InPool("jobs"))
+//	}
+//	
+//	// ...
+//	
+//	id, err := queue.Enqueue(payload)
+//
+// If `queue` is closed (i.e., Dequeue returns ErrQueueClosed), then the returned toiler
+// returns. If `queue` returns any other error (other than because the context is done),
+// then the returned toiler panic()s with it. (Again, so that the Group's PanicPolicy and
+// RestartPolicy apply.)
+//
+// The returned toiler is also a Stopper, that stops its current runs dequeueing tasks.
+// (A task that `handler` is doing is not interrupted, unless `handler` watches its
+// context.)
+func ConsumeQueue(queue Queue, handler TaskHandler) ContextToiler {
+	consumer := queueConsumer{
+		queue:queue,
+		handler:handler,
+	}
+
+	return &consumer
+}
+
+
+type queueConsumer struct {
+	queue   Queue
+	handler TaskHandler
+	runs    runCancels
+}
+
+
+// Toil is part of the Toiler interface.
+func (consumer *queueConsumer) Toil() {
+	consumer.ToilContext(context.Background())
+}
+
+
+// ToilContext is part of the ContextToiler interface.
+func (consumer *queueConsumer) ToilContext(ctx context.Context) {
+	ctx, end := consumer.runs.begin(ctx)
+	defer end()
+
+	for {
+		task, err := consumer.queue.Dequeue(ctx)
+		if nil != err {
+			if nil != ctx.Err() || errors.Is(err, ErrQueueClosed) {
+				return
+			}
+			panic(err)
+		}
+
+		consumer.handle(ctx, task)
+	}
+}
+
+
+// handle does a task with the handler, and then acknowledges it (or not).
+//
+// NOTE that the errors from Ack and Nack are ignored, since if acknowledging a task
+// fails, then the task is (just) delivered again, after the visibility timeout.
+func (consumer *queueConsumer) handle(ctx context.Context, task Task) {
+
+	// If the handler panic()s, then we negatively acknowledge the task, and let the
+	// panic() continue on to the Group. (We do not recover the panic here, so that
+	// the Group gets the stack trace of where it actually happened.)
+	handled := false
+	defer func() {
+		if !handled {
+			consumer.queue.Nack(task.Receipt)
+		}
+	}()
+
+	err := consumer.handler(ctx, task)
+	handled = true
+
+	if nil != err {
+		consumer.queue.Nack(task.Receipt)
+		return
+	}
+
+	consumer.queue.Ack(task.Receipt)
+}
+
+
+// Stop is part of the Stopper interface.
+func (consumer *queueConsumer) Stop() {
+	consumer.runs.cancelAll()
+}
@@ -0,0 +1,476 @@
+package toil
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil/toiltest"
+
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+
+func TestFileQueueSurvivesReopening(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+
+	queue, err := NewFileQueue(path)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	for _,payload := range []string{"apple", "banana", "cherry"} {
+		if _, err := queue.Enqueue([]byte(payload)); nil != err {
+			t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+		}
+	}
+
+	ctx := context.Background()
+
+	apple, err := queue.Dequeue(ctx)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if err := queue.Ack(apple.Receipt); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	// NOTE that banana is delivered, but never acknowledged (as if the process crashed).
+	banana, err := queue.Dequeue(ctx)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if expected, actual := "banana", string(banana.Payload); expected != actual {
+		t.Errorf("Expected the payload to be %q, but actually was %q.", expected, actual)
+	}
+
+	if err := queue.Close(); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	// An incomplete last line (as if the process crashed while writing it).
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	file.Write([]byte(`{"op":"ack","id":`))
+	file.Close()
+
+	queue, err = NewFileQueue(path)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer queue.Close()
+
+	if expected, actual := 2, queue.Len(); expected != actual {
+		t.Errorf("Expected the number of tasks to be %d, but actually was %d.", expected, actual)
+	}
+
+	task, err := queue.Dequeue(ctx)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if expected, actual := banana.ID, task.ID; expected != actual {
+		t.Errorf("Expected the unacknowledged task %q to be delivered again, but actually was %q.", expected, actual)
+	}
+	if expected, actual := 2, task.Attempts; expected != actual {
+		t.Errorf("Expected the number of attempts to be %d, but actually was %d.", expected, actual)
+	}
+
+	id, err := queue.Enqueue([]byte("date"))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if id == apple.ID || id == banana.ID {
+		t.Errorf("Expected a new ID, but actually was %q.", id)
+	}
+}
+
+
+func TestFileQueueVisibilityTimeout(t *testing.T) {
+
+	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	queue, err := NewFileQueue(filepath.Join(t.TempDir(), "jobs.jsonl"), MaxAttempts(2), VisibilityTimeout(time.Minute), QueueClock(clock))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer queue.Close()
+
+	if _, err := queue.Enqueue([]byte("apple")); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	ctx := context.Background()
+
+	first, err := queue.Dequeue(ctx)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	taskCh := make(chan Task, 1)
+	go func() {
+		task, _ := queue.Dequeue(ctx)
+		taskCh <- task
+	}()
+
+	clock.BlockUntilWaiters(1)
+	clock.Advance(time.Minute)
+
+	select {
+	case second := <-taskCh:
+		if expected, actual := first.ID, second.ID; expected != actual {
+			t.Errorf("Expected the task %q to be delivered again, but actually was %q.", expected, actual)
+		}
+		if expected, actual := 2, second.Attempts; expected != actual {
+			t.Errorf("Expected the number of attempts to be %d, but actually was %d.", expected, actual)
+		}
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected the task to be delivered again after its visibility timeout, but it was not.")
+	}
+
+	// The second time its visibility timeout runs out, it has been delivered too many
+	// times, so it is dead-lettered.
+	clock.Advance(time.Minute)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := queue.Dequeue(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected no task to be delivered, but actually got: (%T) %v", err, err)
+	}
+
+	deadLetters := queue.DeadLetters()
+	if expected, actual := 1, len(deadLetters); expected != actual {
+		t.Fatalf("Expected the number of dead letters to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := "apple", string(deadLetters[0].Payload); expected != actual {
+		t.Errorf("Expected the dead letter's payload to be %q, but actually was %q.", expected, actual)
+	}
+}
+
+
+func TestConsumeQueueDeadLettersPanics(t *testing.T) {
+
+	queue, err := NewFileQueue(filepath.Join(t.TempDir(), "jobs.jsonl"), MaxAttempts(3))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer queue.Close()
+
+	var mutex sync.Mutex
+	var done []string
+	numPanics := 0
+
+	handler := func(ctx context.Context, task Task) error {
+		if "poison" == string(task.Payload) {
+			mutex.Lock()
+			numPanics++
+			mutex.Unlock()
+
+			panic("poison")
+		}
+
+		mutex.Lock()
+		done = append(done, string(task.Payload))
+		mutex.Unlock()
+
+		return nil
+	}
+
+	for _,payload := range []string{"apple", "poison", "banana"} {
+		if _, err := queue.Enqueue([]byte(payload)); nil != err {
+			t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+		}
+	}
+
+	group := NewGroup(Isolate, Pool("jobs", RestartOnPanic))
+	group.RegisterWith(ConsumeQueue(queue, handler), InPool("jobs"))
+
+	toiledCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(toiledCh)
+	}()
+
+	toiltest.AssertEventually(t, 5*time.Second, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return 1 == len(queue.DeadLetters()) && 2 == len(done)
+	})
+
+	group.Stop()
+
+	select {
+	case <-toiledCh:
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected Toil() to return after Stop(), but it did not.")
+	}
+
+	if expected, actual := 0, queue.Len(); expected != actual {
+		t.Errorf("Expected the number of tasks left to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 3, numPanics; expected != actual {
+		t.Errorf("Expected the number of panics to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := 3, group.Status()[0].Panics; expected != actual {
+		t.Errorf("Expected the number of panics in the toiler's status to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestFileQueueDeadLettersAfterCrashes(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+
+	queue, err := NewFileQueue(path, MaxAttempts(2))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if _, err := queue.Enqueue([]byte("poison")); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	queue.Close()
+
+	// Each time, the task is delivered, but the process crashes (so the task is never
+	// acknowledged, or negatively acknowledged).
+	for attempt:=1; attempt<=2; attempt++ {
+		queue, err := NewFileQueue(path, MaxAttempts(2))
+		if nil != err {
+			t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+		}
+
+		task, err := queue.Dequeue(context.Background())
+		if nil != err {
+			t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+		}
+		if expected, actual := attempt, task.Attempts; expected != actual {
+			t.Errorf("Expected the number of attempts to be %d, but actually was %d.", expected, actual)
+		}
+
+		queue.Close()
+	}
+
+	queue, err = NewFileQueue(path, MaxAttempts(2))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer queue.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if task, err := queue.Dequeue(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the task to not be delivered again, but actually got: %#v (error: %v)", task, err)
+	}
+
+	if expected, actual := 1, len(queue.DeadLetters()); expected != actual {
+		t.Errorf("Expected the number of dead letters to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestFileQueueStaleReceipts(t *testing.T) {
+
+	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	queue, err := NewFileQueue(filepath.Join(t.TempDir(), "jobs.jsonl"), MaxAttempts(5), VisibilityTimeout(time.Minute), QueueClock(clock))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer queue.Close()
+
+	if _, err := queue.Enqueue([]byte("apple")); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	ctx := context.Background()
+
+	// A's delivery times out, and the task is delivered again, to B.
+	a, err := queue.Dequeue(ctx)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	clock.Advance(time.Minute)
+
+	b, err := queue.Dequeue(ctx)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if expected, actual := a.ID, b.ID; expected != actual {
+		t.Fatalf("Expected the task %q to be delivered again, but actually was %q.", expected, actual)
+	}
+	if a.Receipt == b.Receipt {
+		t.Errorf("Expected each delivery to have its own receipt, but both were %q.", a.Receipt)
+	}
+
+	// A's late Nack must not make B's delivery deliverable again.
+	if expected, actual := ErrStaleReceipt, queue.Nack(a.Receipt); expected != actual {
+		t.Errorf("Expected A's Nack() to return error %v, but actually was %v.", expected, actual)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if task, err := queue.Dequeue(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the task to still be in flight (with B), but actually got: %#v (error: %v)", task, err)
+	}
+
+	// A's late Ack must not remove the task while B is doing it.
+	if expected, actual := ErrStaleReceipt, queue.Ack(a.Receipt); expected != actual {
+		t.Errorf("Expected A's Ack() to return error %v, but actually was %v.", expected, actual)
+	}
+	if expected, actual := 1, queue.Len(); expected != actual {
+		t.Errorf("Expected the number of tasks to be %d, but actually was %d.", expected, actual)
+	}
+
+	if err := queue.Ack(b.Receipt); nil != err {
+		t.Errorf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if expected, actual := 0, queue.Len(); expected != actual {
+		t.Errorf("Expected the number of tasks to be %d, but actually was %d.", expected, actual)
+	}
+
+	// B's Ack is now stale too.
+	if expected, actual := ErrStaleReceipt, queue.Ack(b.Receipt); expected != actual {
+		t.Errorf("Expected a second Ack() to return error %v, but actually was %v.", expected, actual)
+	}
+}
+
+
+func TestFileQueueSkipsMalformedLines(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+
+	lines := `{"op":"enqueue","id":"0","payload":"YXBwbGU="}` + "\n" +
+		`{"op":"enq` + "\n" +
+		`{"op":"enqueue","id":"1","payload":"YmFuYW5h"}` + "\n" +
+		"garbage\n" +
+		`{"op":"ack","id":"0"}` + "\n"
+
+	if err := os.WriteFile(path, []byte(lines), 0644); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	queue, err := NewFileQueue(path)
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer queue.Close()
+
+	if expected, actual := 1, queue.Len(); expected != actual {
+		t.Fatalf("Expected the number of tasks to be %d, but actually was %d.", expected, actual)
+	}
+
+	task, err := queue.Dequeue(context.Background())
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if expected, actual := "banana", string(task.Payload); expected != actual {
+		t.Errorf("Expected the payload to be %q, but actually was %q.", expected, actual)
+	}
+}
+
+
+func TestConsumeQueueClosed(t *testing.T) {
+
+	queue, err := NewFileQueue(filepath.Join(t.TempDir(), "jobs.jsonl"))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	var numHandled int32
+
+	toiler := ConsumeQueue(queue, func(ctx context.Context, task Task) error {
+		atomic.AddInt32(&numHandled, 1)
+		return nil
+	})
+
+	// NOTE that the default PanicPolicy (Propagate) is used, so a panic() would make
+	// Toil() panic().
+	group := groupOf(toiler)
+
+	if _, err := queue.Enqueue([]byte("apple")); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	go func() {
+		if eventually(5*time.Second, func() bool { return 1 == atomic.LoadInt32(&numHandled) }) {
+			queue.Close()
+		}
+	}()
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return (without panic()ing) after the queue was closed, but it did not. (Panic value: %v)", panicValue)
+	}
+}
+
+
+func TestConsumeQueueStopIsPerRun(t *testing.T) {
+
+	queue, err := NewFileQueue(filepath.Join(t.TempDir(), "jobs.jsonl"))
+	if nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	defer queue.Close()
+
+	handledCh := make(chan string, 1)
+
+	toiler := ConsumeQueue(queue, func(ctx context.Context, task Task) error {
+		handledCh <- string(task.Payload)
+		return nil
+	})
+
+	doneCh := make(chan struct{})
+	go func() {
+		toiler.Toil()
+		close(doneCh)
+	}()
+
+	// NOTE that a task is handled first, so that the run has surely started before
+	// it is stopped.
+	if _, err := queue.Enqueue([]byte("first")); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	select {
+	case <-handledCh:
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected the toiler to handle the task, but it did not.")
+	}
+
+	toiler.(Stopper).Stop()
+
+	select {
+	case <-doneCh:
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected the toiler to return after being stopped, but it did not.")
+	}
+
+	// Stopping cancelled the first run's Dequeue. A second run (such as after the Group
+	// restarts the toiler) still dequeues, and handles, the tasks enqueued after that.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go toiler.ToilContext(ctx)
+
+	if _, err := queue.Enqueue([]byte("apple")); nil != err {
+		t.Fatalf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+
+	select {
+	case payload := <-handledCh:
+		if expected, actual := "apple", payload; expected != actual {
+			t.Errorf("Expected the payload to be %q, but actually was %q.", expected, actual)
+		}
+	case <-time.After(5*time.Second):
+		t.Errorf("Expected the later run to handle the task, but it did not.")
+	}
+}
@@ -0,0 +1,110 @@
+package toil
+
+
+import (
+	"time"
+)
+
+
+// QueueOption is an option that can be passed to NewFileQueue.
+//
+// For example:
+//
+//	queue, err := toil.NewFileQueue("/var/lib/awesome/jobs.jsonl", toil.MaxAttempts(3))
+type QueueOption interface {
+	applyQueueOption(*queueConfig)
+}
+
+
+// queueConfig is the configuration of a queue, as built up from the QueueOption(s)
+// passed to NewFileQueue.
+type queueConfig struct {
+	maxAttempts       int
+	visibilityTimeout time.Duration
+	clock             Clock
+}
+
+
+func newQueueConfig(options ...QueueOption) queueConfig {
+	config := queueConfig{
+		maxAttempts:5,
+		visibilityTimeout:30*time.Second,
+		clock:realClock{},
+	}
+
+	for _,option := range options {
+		if nil == option {
+			continue
+		}
+		option.applyQueueOption(&config)
+	}
+
+	return config
+}
+
+
+// MaxAttempts returns a QueueOption that makes a queue dead-letter a task once it has
+// been delivered `n` times without being acknowledged. (The default is 5.)
+//
+// If `n` is not positive, then tasks are never dead-lettered.
+func MaxAttempts(n int) QueueOption {
+	return maxAttemptsOption{
+		n:n,
+	}
+}
+
+
+type maxAttemptsOption struct {
+	n int
+}
+
+
+func (option maxAttemptsOption) applyQueueOption(config *queueConfig) {
+	config.maxAttempts = option.n
+}
+
+
+// VisibilityTimeout returns a QueueOption that makes a queue deliver a task again if it
+// has not been acknowledged (or negatively acknowledged) within `d` of being delivered.
+// (The default is 30 seconds.)
+//
+// A task that takes longer than `d` to do will be delivered again while it is still
+// being done. So, `d` should be longer than the longest a task should take.
+func VisibilityTimeout(d time.Duration) QueueOption {
+	return visibilityTimeoutOption{
+		d:d,
+	}
+}
+
+
+type visibilityTimeoutOption struct {
+	d time.Duration
+}
+
+
+func (option visibilityTimeoutOption) applyQueueOption(config *queueConfig) {
+	config.visibilityTimeout = option.d
+}
+
+
+// QueueClock returns a QueueOption that makes a queue use `clock` for its visibility
+// timeouts. (The default is the real clock.) (See Clock.)
+func QueueClock(clock Clock) QueueOption {
+	return queueClockOption{
+		clock:clock,
+	}
+}
+
+
+type queueClockOption struct {
+	clock Clock
+}
+
+
+func (option queueClockOption) applyQueueOption(config *queueConfig) {
+	if nil == option.clock {
+		return
+	}
+
+	config.clock = option.clock
+}
@@ -0,0 +1,238 @@
+package toil
+
+
+import (
+	"sync"
+	"time"
+)
+
+
+// registeredToiler is a toiler registered with a Group, along with its configuration
+// and what the Group keeps track of about it (for its status).
+type registeredToiler struct {
+	toiler Toiler
+	config registerConfig
+	index  int        // NOTE that this is set by the daemon when the toiler is registered.
+	pool   *toilerPool // NOTE that this is set by the daemon when the toiler is registered.
+
+	mutex       sync.Mutex
+	run         *toilRun // The current run. (Or nil, if not toiling.)
+	numRuns     int
+	numPanics   int
+	numTimedOut int
+	numRestarts int
+	numBackoffs int       // The number of restarts in a row. (See restartDelay.)
+	lastBegan   time.Time // When the last run began.
+	paused      bool
+	resumeCh    chan struct{} // NOTE that this is closed when a paused toiler is resumed.
+}
+
+
+// closedCh is a channel that is always closed.
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+
+// begin records that `run` is the toiler's current run, and returns its run number.
+//
+// If the toiler is paused, then it does not, and returns false.
+func (registration *registeredToiler) begin(run *toilRun) (int, bool) {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	if registration.paused {
+		return 0, false
+	}
+
+	registration.numRuns++
+	registration.run = run
+	registration.lastBegan = run.began
+	run.number = registration.numRuns
+
+	return registration.numRuns, true
+}
+
+
+// end records that `run` is no longer the toiler's current run. (If it still was.)
+func (registration *registeredToiler) end(run *toilRun) {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	if run == registration.run {
+		registration.run = nil
+	}
+}
+
+
+func (registration *registeredToiler) countPanic() {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	registration.numPanics++
+}
+
+
+func (registration *registeredToiler) countTimedOut() {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	registration.numTimedOut++
+}
+
+
+// pause marks the toiler as paused, and marks its current run (if any) as paused,
+// and returns that run. It returns false if the toiler was already paused.
+func (registration *registeredToiler) pause() (bool, *toilRun) {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	if registration.paused {
+		return false, nil
+	}
+
+	registration.paused   = true
+	registration.resumeCh = make(chan struct{})
+
+	run := registration.run
+	if nil != run {
+		run.paused = true
+	}
+
+	return true, run
+}
+
+
+// resume marks the toiler as no longer paused. It returns false if the toiler
+// was not paused.
+func (registration *registeredToiler) resume() bool {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	if !registration.paused {
+		return false
+	}
+
+	registration.paused = false
+	close(registration.resumeCh)
+
+	return true
+}
+
+
+// resumed returns a channel that is closed when the toiler is (or already is) not paused.
+func (registration *registeredToiler) resumed() <-chan struct{} {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	if !registration.paused {
+		return closedCh
+	}
+
+	return registration.resumeCh
+}
+
+
+// wasPaused returns whether `run` was stopped because the toiler was paused.
+func (registration *registeredToiler) wasPaused(run *toilRun) bool {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	return run.paused
+}
+
+
+// markRestarting marks the toiler's current run as restarting, and returns that run.
+// It returns nil if the toiler is not toiling, is paused, or its current run was already
+// marked as restarting.
+func (registration *registeredToiler) markRestarting() *toilRun {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	run := registration.run
+	if nil == run || run.restarting || registration.paused {
+		return nil
+	}
+
+	run.restarting = true
+
+	return run
+}
+
+
+// wasRestarting returns whether `run` was stopped so that the toiler would toil again.
+// (See markRestarting.)
+func (registration *registeredToiler) wasRestarting(run *toilRun) bool {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	return run.restarting
+}
+
+
+func (registration *registeredToiler) countRestart() {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	registration.numRestarts++
+}
+
+
+// flagHung flags the toiler's current run as hung, if its heartbeat is older than
+// `threshold`, and returns that run.
+//
+// It returns nil if the toiler is not toiling, if its current run is not watched, if
+// its current run is not hung, or if its current run was already flagged as hung.
+func (registration *registeredToiler) flagHung(now time.Time, threshold time.Duration) *toilRun {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	run := registration.run
+	if nil == run {
+		return nil
+	}
+
+	last, watched := run.heartbeat.watched()
+	if !watched || now.Sub(last) <= threshold {
+		run.hung = false
+		return nil
+	}
+
+	if run.hung {
+		return nil
+	}
+	run.hung = true
+
+	return run
+}
+
+
+// status returns the status of the toiler.
+func (registration *registeredToiler) status() ToilerStatus {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	status := ToilerStatus{
+		Name:registration.name(),
+		Pool:registration.pool.name,
+		Toiler:registration.toiler,
+		Runs:registration.numRuns,
+		Restarts:registration.numRestarts,
+		Paused:registration.paused,
+		Panics:registration.numPanics,
+		TimedOut:registration.numTimedOut,
+	}
+
+	if run := registration.run; nil != run {
+		status.Toiling = true
+		status.Hung    = run.hung
+
+		if last, watched := run.heartbeat.watched(); watched {
+			status.LastHeartbeat = last
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,41 @@
+package toil
+
+
+import (
+	"time"
+)
+
+
+// RegisterOption is an option that can be passed to a Group's RegisterWith method,
+// to configure how the Group makes that toiler toil.
+//
+// For example:
+//
+//	group.RegisterWith(toiler, toil.Timeout(5*time.Minute))
+type RegisterOption interface {
+	applyRegisterOption(*registerConfig)
+}
+
+
+// registerConfig is the configuration of a registered toiler, as built up from the
+// RegisterOption(s) passed to RegisterWith.
+type registerConfig struct {
+	name     string
+	timeout  time.Duration
+	priority int
+	pool     string
+}
+
+
+func newRegisterConfig(options ...RegisterOption) registerConfig {
+	var config registerConfig
+
+	for _,option := range options {
+		if nil == option {
+			continue
+		}
+		option.applyRegisterOption(&config)
+	}
+
+	return config
+}
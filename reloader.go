@@ -0,0 +1,148 @@
+package toil
+
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+)
+
+
+// Reloader is an interface that wraps the Reload method.
+//
+// If a toiler is also a Reloader, then its Reload method will be called when the
+// Group it is registered with is asked to reload, via the Group's Reload method.
+//
+// The Reload method should make the toiler (re)load its configuration, without it
+// having to stop toiling.
+//
+// Toilers that are not Reloaders are instead stopped (by cancelling the context of
+// their run) and restarted. Unless they are not ContextToilers either, in which case
+// their run cannot be interrupted, and so they are not restarted. (See the Group's
+// Reload method, and ContextToiler.)
+type Reloader interface {
+	Reload(context.Context) error
+}
+
+
+// ReloadOutcome is the outcome of asking a toiler to reload. (See the Group's Reload
+// method.)
+type ReloadOutcome struct {
+	// Name is the name of the toiler. (See Name.)
+	Name string
+
+	Toiler Toiler
+
+	// Reloaded is whether the toiler is a Reloader, and its Reload method was called.
+	Reloaded bool
+
+	// Restarted is whether the toiler (not being a Reloader) was stopped and restarted.
+	//
+	// A toiler that was not toiling (for example, because it is paused) is not restarted.
+	// Nor is a toiler that is not a ContextToiler (since its run cannot be interrupted).
+	Restarted bool
+
+	// Err is the error the toiler's Reload method returned (or a *PanicError, if it
+	// panic()ed), if any.
+	Err error
+}
+
+
+func (group *internalGroup) Reload(ctx context.Context) []ReloadOutcome {
+	registrationsReturnCh := make(chan []*registeredToiler, 1) // NOTE that this is buffered, so that the request can also be served by the exited method.
+	defer close(registrationsReturnCh)
+
+	registrationsRequest := struct{returnCh chan []*registeredToiler}{
+		returnCh:registrationsReturnCh,
+	}
+
+	select {
+	case group.daemon.RegistrationsCh() <- registrationsRequest:
+	case <-group.daemon.ExitedCh():
+		group.daemon.exited(func(){
+			group.daemon.serveRegistrations(registrationsRequest)
+		})
+	}
+
+	registrations := <-registrationsReturnCh
+
+	outcomes := make([]ReloadOutcome, len(registrations))
+
+	var waitGroup sync.WaitGroup
+	for i,registration := range registrations {
+		waitGroup.Add(1)
+		go func(outcome *ReloadOutcome, registration *registeredToiler) {
+			defer waitGroup.Done()
+
+			*outcome = group.daemon.reload(ctx, registration)
+		}(&outcomes[i], registration)
+	}
+	waitGroup.Wait()
+
+	return outcomes
+}
+
+
+// reload makes a registered toiler reload. (See the Group's Reload method.)
+func (daemon *internalGroupDaemon) reload(ctx context.Context, registration *registeredToiler) (outcome ReloadOutcome) {
+
+	toiler := registration.toiler
+
+	outcome.Name   = registration.name()
+	outcome.Toiler = toiler
+
+	reloader, ok := toiler.(Reloader)
+	if !ok {
+		outcome.Restarted = daemon.restartToReload(registration)
+		return outcome
+	}
+
+	outcome.Reloaded = true
+
+	defer func() {
+		if panicValue := recover(); nil != panicValue {
+			outcome.Err = &PanicError{
+				Value:panicValue,
+				Stack:debug.Stack(),
+				Toiler:toiler,
+				Time:daemon.config.clock.Now(),
+			}
+		}
+	}()
+
+	outcome.Err = reloader.Reload(ctx)
+
+	return outcome
+}
+
+
+// restartToReload stops a (registered) toiler's current run (by cancelling its context),
+// and makes it toil again. It returns false if the toiler was not toiling (or is paused),
+// or is not a ContextToiler.
+//
+// This is how a toiler that is not a Reloader is reloaded.
+func (daemon *internalGroupDaemon) restartToReload(registration *registeredToiler) bool {
+	if daemon.stopped() {
+		return false
+	}
+
+	// A toiler that is not a ContextToiler cannot be made to stop toiling (without
+	// stopping it for good, with its Stop method), so it cannot be restarted.
+	if _, ok := registration.toiler.(ContextToiler); !ok {
+		return false
+	}
+
+	// NOTE that the run is marked as restarting (rather than, say, paused and then resumed),
+	// so that pausing the toiler at the same time is not undone. (See spawn.)
+	run := registration.markRestarting()
+	if nil == run {
+		return false
+	}
+
+	registration.countRestart()
+	daemon.emit(EventRestarted, registration, 0, nil)
+
+	run.cancel()
+
+	return true
+}
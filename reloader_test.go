@@ -0,0 +1,228 @@
+package toil
+
+
+import (
+	"testing"
+
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+
+type reloadingToiler struct {
+	numReloads int32
+	err        error
+}
+
+func (toiler *reloadingToiler) Toil() {
+	select{}
+}
+
+func (toiler *reloadingToiler) ToilContext(ctx context.Context) {
+	<-ctx.Done()
+}
+
+func (toiler *reloadingToiler) Reload(ctx context.Context) error {
+	atomic.AddInt32(&toiler.numReloads, 1)
+	return toiler.err
+}
+
+
+type panickingReloadingToiler struct {
+	reloadingToiler
+}
+
+func (toiler *panickingReloadingToiler) Reload(ctx context.Context) error {
+	panic("cannot reload")
+}
+
+
+func TestReload(t *testing.T) {
+
+	var numStarted int32
+
+	errReload := errors.New("bad configuration")
+
+	reloader1 := &reloadingToiler{}
+	reloader2 := &reloadingToiler{err:errReload}
+	reloader3 := &panickingReloadingToiler{}
+
+	group := NewGroup()
+	defer group.Stop()
+
+	group.RegisterWith(reloader1, Name("reloader1"))
+	group.RegisterWith(reloader2, Name("reloader2"))
+	group.RegisterWith(reloader3, Name("reloader3"))
+	group.RegisterWith(countingContextToiler(&numStarted), Name("restarter"))
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	outcomes := group.Reload(context.Background())
+
+	if expected, actual := 4, len(outcomes); expected != actual {
+		t.Fatalf("Expected the number of outcomes to be %d, but actually was %d.", expected, actual)
+	}
+
+	if expected, actual := "reloader1", outcomes[0].Name; expected != actual {
+		t.Errorf("Expected the name of outcome #0 to be %q, but actually was %q.", expected, actual)
+	}
+	if !outcomes[0].Reloaded || outcomes[0].Restarted || nil != outcomes[0].Err {
+		t.Errorf("Expected outcome #0 to be reloaded without error, but actually was: %#v", outcomes[0])
+	}
+	if expected, actual := int32(1), atomic.LoadInt32(&reloader1.numReloads); expected != actual {
+		t.Errorf("Expected the number of reloads to be %d, but actually was %d.", expected, actual)
+	}
+
+	if expected, actual := errReload, outcomes[1].Err; expected != actual {
+		t.Errorf("Expected the error of outcome #1 to be %v, but actually was %v.", expected, actual)
+	}
+
+	var panicError *PanicError
+	if !errors.As(outcomes[2].Err, &panicError) {
+		t.Errorf("Expected the error of outcome #2 to be a *PanicError, but actually was %#v.", outcomes[2].Err)
+	} else if expected, actual := "cannot reload", panicError.Value; expected != actual {
+		t.Errorf("Expected the panic value to be %q, but actually was %v.", expected, actual)
+	}
+
+	if outcomes[3].Reloaded || !outcomes[3].Restarted || nil != outcomes[3].Err {
+		t.Errorf("Expected outcome #3 to be restarted, but actually was: %#v", outcomes[3])
+	}
+
+	if !eventually(time.Second, func() bool { return 2 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the restarted toiler to have started toiling again, but it did not.")
+	}
+
+	status := group.Status()[3]
+
+	if expected, actual := 1, status.Restarts; expected != actual {
+		t.Errorf("Expected the number of restarts to be %d, but actually was %d.", expected, actual)
+	}
+
+	if expected, actual := false, status.Paused; expected != actual {
+		t.Errorf("Expected the status to say paused was %t, but actually was %t.", expected, actual)
+	}
+}
+
+
+func TestReloadPaused(t *testing.T) {
+
+	var numStarted int32
+
+	group := NewGroup()
+	defer group.Stop()
+
+	registration := group.RegisterWith(countingContextToiler(&numStarted))
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	registration.Pause()
+
+	outcomes := group.Reload(context.Background())
+
+	if expected, actual := false, outcomes[0].Restarted; expected != actual {
+		t.Errorf("Expected restarted to be %t, but actually was %t.", expected, actual)
+	}
+
+	if expected, actual := true, registration.Status().Paused; expected != actual {
+		t.Errorf("Expected the toiler to still be paused (%t), but actually was %t.", expected, actual)
+	}
+}
+
+
+func TestReloadNotContextToiler(t *testing.T) {
+
+	var numStarted int32
+
+	releaseCh := make(chan struct{})
+
+	group := NewGroup()
+	defer group.Stop()
+
+	registration := group.RegisterWith( ToilerFunc(func(){
+		atomic.AddInt32(&numStarted, 1)
+		<-releaseCh
+	}) )
+	defer close(releaseCh)
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	outcomes := group.Reload(context.Background())
+
+	if expected, actual := false, outcomes[0].Restarted; expected != actual {
+		t.Errorf("Expected restarted to be %t (since the toiler's run cannot be interrupted), but actually was %t.", expected, actual)
+	}
+
+	status := registration.Status()
+
+	if expected, actual := 0, status.Restarts; expected != actual {
+		t.Errorf("Expected the number of restarts to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := true, status.Toiling; expected != actual {
+		t.Errorf("Expected the toiler to still be toiling (%t), but actually was %t.", expected, actual)
+	}
+}
+
+
+func TestReloadDoesNotUndoPause(t *testing.T) {
+
+	var numStarted int32
+
+	var registration Registration
+
+	group := NewGroup()
+	defer group.Stop()
+
+	// The first run pauses the toiler as it is being restarted (to reload it).
+	registration = group.RegisterWith(ContextToilerFunc(func(ctx context.Context) {
+		n := atomic.AddInt32(&numStarted, 1)
+		<-ctx.Done()
+		if 1 == n {
+			registration.Pause()
+		}
+	}))
+
+	go group.Toil()
+
+	if !eventually(time.Second, func() bool { return 1 == atomic.LoadInt32(&numStarted) }) {
+		t.Fatalf("Expected the toiler to have started toiling, but it did not.")
+	}
+
+	outcomes := group.Reload(context.Background())
+
+	if expected, actual := true, outcomes[0].Restarted; expected != actual {
+		t.Errorf("Expected restarted to be %t, but actually was %t.", expected, actual)
+	}
+
+	if !eventually(time.Second, func() bool { return !registration.Status().Toiling }) {
+		t.Fatalf("Expected the toiler to have stopped toiling, but it did not.")
+	}
+
+	time.Sleep(20*time.Millisecond)
+
+	if expected, actual := true, registration.Status().Paused; expected != actual {
+		t.Errorf("Expected the toiler to still be paused (%t), but actually was %t.", expected, actual)
+	}
+	if expected, actual := int32(1), atomic.LoadInt32(&numStarted); expected != actual {
+		t.Errorf("Expected the number of runs to be %d (since the toiler is paused), but actually was %d.", expected, actual)
+	}
+
+	registration.Resume()
+
+	if !eventually(time.Second, func() bool { return 2 == atomic.LoadInt32(&numStarted) }) {
+		t.Errorf("Expected the resumed toiler to have started toiling again, but it did not.")
+	}
+}
@@ -0,0 +1,84 @@
+package toil
+
+
+import (
+	"time"
+)
+
+
+// RestartBackoff returns a GroupOption that makes a Group (or a Pool) wait before it
+// restarts a toiler (see RestartPolicy), so that a toiler that keeps failing right away
+// does not keep the CPU busy restarting it.
+//
+// The first restart waits `initial`, and each restart after that waits twice as long
+// as the one before it, up to `max`. Once a run of the toiler has lasted (at least)
+// `max`, the wait goes back to `initial`. For example:
+//
+//	group := toil.NewGroup(toil.RestartOnPanic, toil.RestartBackoff(100*time.Millisecond, time.Minute))
+//
+// If no RestartBackoff is given, then the wait starts at 10 milliseconds, and goes up
+// to 10 seconds. RestartBackoff(0, 0) makes restarts not wait.
+func RestartBackoff(initial time.Duration, max time.Duration) GroupOption {
+	return restartBackoffOption{
+		initial:initial,
+		max:max,
+	}
+}
+
+
+type restartBackoffOption struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+
+// defaultRestartBackoff is the RestartBackoff used if none is given.
+var defaultRestartBackoff = restartBackoffOption{
+	initial:10*time.Millisecond,
+	max:10*time.Second,
+}
+
+
+func (option restartBackoffOption) applyGroupOption(config *groupConfig) {
+	config.restartBackoff = option
+}
+
+
+// delay returns how long to wait before a restart, when there have been `n` restarts
+// (of the same toiler) in a row before it.
+func (option restartBackoffOption) delay(n int) time.Duration {
+	if option.initial <= 0 {
+		return 0
+	}
+
+	d := option.initial
+	for i:=0; i<n && d < option.max; i++ {
+		d *= 2
+	}
+
+	if option.max < d {
+		d = option.max
+	}
+
+	return d
+}
+
+
+// restartDelay returns how long to wait before restarting the toiler, and counts the
+// restart (for the backoff).
+//
+// If the toiler's last run lasted (at least) the backoff's max, then the backoff starts
+// over.
+func (registration *registeredToiler) restartDelay(now time.Time, option restartBackoffOption) time.Duration {
+	registration.mutex.Lock()
+	defer registration.mutex.Unlock()
+
+	if !registration.lastBegan.IsZero() && option.max <= now.Sub(registration.lastBegan) {
+		registration.numBackoffs = 0
+	}
+
+	d := option.delay(registration.numBackoffs)
+	registration.numBackoffs++
+
+	return d
+}
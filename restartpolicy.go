@@ -0,0 +1,82 @@
+package toil
+
+
+import (
+	"fmt"
+)
+
+
+type restartPolicyKind int
+
+const (
+	neverRestartPolicyKind restartPolicyKind = iota
+	restartOnPanicPolicyKind
+	restartAlwaysPolicyKind
+)
+
+
+// RestartPolicy decides whether a Group restarts a toiler (i.e., makes it toil again)
+// after one of its runs finishes.
+//
+// A RestartPolicy is also a GroupOption, and is given to a Group (or a Pool) by passing
+// it to NewGroup (or Pool). For example:
+//
+//	group := toil.NewGroup(toil.RestartOnPanic)
+//
+// If no RestartPolicy is given, then NeverRestart is used.
+//
+// Restarts go through the same MaxConcurrency and StartRate limits as any other start.
+// And restarts of a toiler that keeps failing are backed off. (See RestartBackoff.)
+type RestartPolicy struct {
+	kind restartPolicyKind
+}
+
+
+var (
+	// NeverRestart never restarts a toiler.
+	//
+	// This is the default RestartPolicy.
+	NeverRestart = RestartPolicy{kind:neverRestartPolicyKind}
+
+	// RestartOnPanic restarts a toiler whose run panic()ed (or timed out).
+	//
+	// The panic is recovered from (and the toiler's RecoveredNotice method is called,
+	// if it has one), rather than the Group's PanicPolicy being applied to it.
+	RestartOnPanic = RestartPolicy{kind:restartOnPanicPolicyKind}
+
+	// RestartAlways restarts a toiler whose run panic()ed (or timed out), like
+	// RestartOnPanic, and also restarts a toiler whose run returned gracefully.
+	//
+	// (A toiler is not restarted if the Group has been stopped.)
+	RestartAlways = RestartPolicy{kind:restartAlwaysPolicyKind}
+)
+
+
+func (policy RestartPolicy) applyGroupOption(config *groupConfig) {
+	config.restartPolicy = policy
+}
+
+
+func (policy RestartPolicy) restartsOnPanic() bool {
+	return restartOnPanicPolicyKind == policy.kind || restartAlwaysPolicyKind == policy.kind
+}
+
+
+func (policy RestartPolicy) restartsOnReturn() bool {
+	return restartAlwaysPolicyKind == policy.kind
+}
+
+
+// String is part of the fmt.Stringer interface.
+func (policy RestartPolicy) String() string {
+	switch policy.kind {
+	case neverRestartPolicyKind:
+		return "NeverRestart"
+	case restartOnPanicPolicyKind:
+		return "RestartOnPanic"
+	case restartAlwaysPolicyKind:
+		return "RestartAlways"
+	default:
+		return fmt.Sprintf("RestartPolicy(%d)", policy.kind)
+	}
+}
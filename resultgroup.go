@@ -0,0 +1,183 @@
+package toil
+
+
+import (
+	"context"
+	"sync"
+)
+
+
+// ResultGroup is an interface that wraps the Len, Register, RegisterWith, Wait and Stop methods,
+// for a group of toilers that produce results.
+//
+// A ResultGroup is built on the same machinery as a Group. So it can be given the
+// same GroupOption(s), such as a PanicPolicy.
+type ResultGroup[T any] interface {
+
+	// Len returns the number of toilers registered with this ResultGroup.
+	Len() int
+
+	// Register registers a toiler with this ResultGroup.
+	Register(ResultToiler[T])
+
+	// RegisterWith registers a toiler with this ResultGroup, with RegisterOption(s)
+	// that configure how this ResultGroup makes that toiler toil. (Such as a Timeout.)
+	RegisterWith(ResultToiler[T], ...RegisterOption)
+
+	// Wait makes all the toilers registered with this ResultGroup toil (i.e., do work),
+	// by calling each of the registered toilers' Toil methods (with a context derived
+	// from ctx), and returns their results, in the order the toilers were registered.
+	//
+	// Wait blocks until all the toilers have returned.
+	//
+	// Any toiler that did not toil (because this ResultGroup was stopped first)
+	// gets context.Canceled as its error.
+	//
+	// Wait should only be called once.
+	Wait(ctx context.Context) []Result[T]
+
+	// Stop asks all the toilers registered with this ResultGroup to stop toiling,
+	// by cancelling the context their Toil methods were called with.
+	//
+	// If Stop is called before Wait, then Wait will not make any of the toilers toil.
+	Stop()
+}
+
+
+type internalResultGroup[T any] struct {
+	group Supervisor
+
+	mutex    sync.Mutex
+	ctx      context.Context
+	adapters []*resultToilerAdapter[T]
+}
+
+
+// NewResultGroup returns an initialized ResultGroup.
+//
+// NewResultGroup can be given GroupOption(s) to configure the ResultGroup, such as a PanicPolicy.
+func NewResultGroup[T any](options ...GroupOption) ResultGroup[T] {
+	resultGroup := internalResultGroup[T]{
+		group:NewGroup(options...),
+		ctx:context.Background(),
+	}
+
+	return &resultGroup
+}
+
+
+func (resultGroup *internalResultGroup[T]) Len() int {
+	return resultGroup.group.Len()
+}
+
+
+func (resultGroup *internalResultGroup[T]) Register(toiler ResultToiler[T]) {
+	resultGroup.RegisterWith(toiler)
+}
+
+
+func (resultGroup *internalResultGroup[T]) RegisterWith(toiler ResultToiler[T], options ...RegisterOption) {
+	adapter := resultToilerAdapter[T]{
+		resultGroup:resultGroup,
+		toiler:toiler,
+	}
+
+	resultGroup.mutex.Lock()
+	resultGroup.adapters = append(resultGroup.adapters, &adapter)
+	resultGroup.mutex.Unlock()
+
+	resultGroup.group.RegisterWith(&adapter, options...)
+}
+
+
+func (resultGroup *internalResultGroup[T]) Wait(ctx context.Context) []Result[T] {
+	resultGroup.mutex.Lock()
+	resultGroup.ctx = ctx
+	resultGroup.mutex.Unlock()
+
+	resultGroup.group.Toil()
+
+
+	// Any toiler that panic()ed (and was recovered from) gets the *PanicError as its error.
+	for _,panicError := range resultGroup.group.Panics() {
+		adapter, ok := panicError.Toiler.(*resultToilerAdapter[T])
+		if !ok {
+			continue
+		}
+
+		adapter.set(*new(T), panicError)
+	}
+
+
+	resultGroup.mutex.Lock()
+	defer resultGroup.mutex.Unlock()
+
+	results := make([]Result[T], len(resultGroup.adapters))
+	for i,adapter := range resultGroup.adapters {
+		if !adapter.done {
+			results[i].Err = context.Canceled
+			continue
+		}
+
+		results[i] = adapter.result
+	}
+
+	return results
+}
+
+
+func (resultGroup *internalResultGroup[T]) Stop() {
+	resultGroup.group.Stop()
+}
+
+
+func (resultGroup *internalResultGroup[T]) context() context.Context {
+	resultGroup.mutex.Lock()
+	defer resultGroup.mutex.Unlock()
+
+	return resultGroup.ctx
+}
+
+
+// resultToilerAdapter adapts a ResultToiler to be a Toiler, so that it can be
+// registered with a Group, by storing the ResultToiler's result (rather than
+// returning it).
+type resultToilerAdapter[T any] struct {
+	resultGroup *internalResultGroup[T]
+	toiler      ResultToiler[T]
+	result      Result[T] // NOTE that this is protected by the result group's mutex.
+	done        bool      // NOTE that this is protected by the result group's mutex.
+}
+
+
+// Toil is part of the toil.Toiler interface.
+func (adapter *resultToilerAdapter[T]) Toil() {
+	adapter.ToilContext(context.Background())
+}
+
+
+// ToilContext is part of the toil.ContextToiler interface.
+//
+// The ResultToiler is called with a context that is done when either `ctx` (from
+// the Group) or the context passed to the ResultGroup's Wait method is done.
+func (adapter *resultToilerAdapter[T]) ToilContext(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(adapter.resultGroup.context(), cancel)
+	defer stop()
+
+	value, err := adapter.toiler.Toil(ctx)
+
+	adapter.set(value, err)
+}
+
+
+func (adapter *resultToilerAdapter[T]) set(value T, err error) {
+	adapter.resultGroup.mutex.Lock()
+	defer adapter.resultGroup.mutex.Unlock()
+
+	adapter.result.Value = value
+	adapter.result.Err   = err
+	adapter.done         = true
+}
@@ -0,0 +1,120 @@
+package toil
+
+
+import (
+	"testing"
+
+	"context"
+	"errors"
+	"time"
+)
+
+
+func TestResultGroupWait(t *testing.T) {
+
+	const numToilers = 30
+
+	resultGroup := NewResultGroup[int]()
+
+	errOdd := errors.New("odd")
+
+	for i:=0; i<numToilers; i++ {
+		i := i
+		resultGroup.Register( ResultToilerFunc[int](func(ctx context.Context) (int, error) {
+			// Make later registered toilers tend to finish first, to make sure
+			// the results are in registration order, not finishing order.
+			time.Sleep(time.Duration(numToilers-i) * time.Millisecond)
+
+			if 1 == i % 2 {
+				return 0, errOdd
+			}
+			return i*i, nil
+		}) )
+	}
+
+	if expected, actual := numToilers, resultGroup.Len(); expected != actual {
+		t.Errorf("Expected the number of registered toilers to be %d, but actually was %d.", expected, actual)
+		return
+	}
+
+	results := resultGroup.Wait(context.Background())
+
+	if expected, actual := numToilers, len(results); expected != actual {
+		t.Errorf("Expected the number of results to be %d, but actually was %d.", expected, actual)
+		return
+	}
+
+	for i,result := range results {
+		if 1 == i % 2 {
+			if expected, actual := errOdd, result.Err; expected != actual {
+				t.Errorf("For result #%d, expected error to be [%v], but actually was [%v].", i, expected, actual)
+			}
+			continue
+		}
+
+		if nil != result.Err {
+			t.Errorf("For result #%d, expected error to be nil, but actually was [%v].", i, result.Err)
+			continue
+		}
+		if expected, actual := i*i, result.Value; expected != actual {
+			t.Errorf("For result #%d, expected value to be %d, but actually was %d.", i, expected, actual)
+			continue
+		}
+	}
+}
+
+
+func TestResultGroupPanicked(t *testing.T) {
+
+	resultGroup := NewResultGroup[string](Isolate)
+
+	resultGroup.Register( ResultToilerFunc[string](func(ctx context.Context) (string, error) {
+		return "apple", nil
+	}) )
+	resultGroup.Register( ResultToilerFunc[string](func(ctx context.Context) (string, error) {
+		panic("banana")
+	}) )
+
+	results := resultGroup.Wait(context.Background())
+
+	if expected, actual := 2, len(results); expected != actual {
+		t.Errorf("Expected the number of results to be %d, but actually was %d.", expected, actual)
+		return
+	}
+
+	if expected, actual := "apple", results[0].Value; expected != actual {
+		t.Errorf("Expected value to be %q, but actually was %q.", expected, actual)
+	}
+
+	panicError, ok := results[1].Err.(*PanicError)
+	if !ok {
+		t.Errorf("Expected error to be a *PanicError, but actually was: (%T) %v", results[1].Err, results[1].Err)
+		return
+	}
+	if expected, actual := "banana", panicError.Value; expected != actual {
+		t.Errorf("Expected panic value to be [%v], but actually was [%v].", expected, actual)
+	}
+}
+
+
+func TestResultGroupStopGroup(t *testing.T) {
+
+	resultGroup := NewResultGroup[int](StopGroup)
+
+	resultGroup.Register( ResultToilerFunc[int](func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}) )
+	resultGroup.Register( ResultToilerFunc[int](func(ctx context.Context) (int, error) {
+		panic("stop everything")
+	}) )
+
+	results := resultGroup.Wait(context.Background())
+
+	if expected, actual := context.Canceled, results[0].Err; expected != actual {
+		t.Errorf("Expected error to be [%v], but actually was [%v].", expected, actual)
+	}
+	if _, ok := results[1].Err.(*PanicError); !ok {
+		t.Errorf("Expected error to be a *PanicError, but actually was: (%T) %v", results[1].Err, results[1].Err)
+	}
+}
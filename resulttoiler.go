@@ -0,0 +1,49 @@
+package toil
+
+
+import (
+	"context"
+)
+
+
+// ResultToiler is an interface that wraps the Toil method, for toilers that
+// produce a result.
+//
+// The purpose of the Toil method is to do work, and return the result of that
+// work (or an error).
+// The Toil method should block while it is doing work, and should return early
+// if the context is cancelled.
+//
+// ResultToilers are registered with a ResultGroup.
+type ResultToiler[T any] interface {
+	Toil(context.Context) (T, error)
+}
+
+
+// The ResultToilerFunc type is an adapter to allow the use of ordinary functions as result toilers.
+// If fn is a function with the appropriate signature, ResultToilerFunc[T](fn) is a ResultToiler[T] that calls fn.
+//
+// Example:
+//
+//	func fn(ctx context.Context) (int, error) {
+//		//@TODO
+//	}
+//	
+//	var toiler ResultToiler[int] = ResultToilerFunc[int](fn)
+type ResultToilerFunc[T any] func(context.Context) (T, error)
+
+
+// Toil calls fn(ctx).
+func (fn ResultToilerFunc[T]) Toil(ctx context.Context) (T, error) {
+	return fn(ctx)
+}
+
+
+// Result is the result of a ResultToiler's Toil method.
+//
+// If the ResultToiler's Toil method panic()ed (and the ResultGroup's PanicPolicy
+// recovered from it), then Err is the *PanicError.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
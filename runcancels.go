@@ -0,0 +1,53 @@
+package toil
+
+
+import (
+	"context"
+	"sync"
+)
+
+
+// runCancels keeps track of how to cancel the current runs of a toiler, so that its
+// Stop method can cancel them.
+//
+// NOTE that this is per run (rather than once and for all), so that stopping the current
+// runs does not keep later runs (such as after the toiler is restarted) from toiling.
+//
+// The zero value is ready to use.
+type runCancels struct {
+	mutex   sync.Mutex
+	cancels map[*context.CancelFunc]struct{}
+}
+
+
+// begin returns the context for a run (derived from `ctx`), which is cancelled when
+// cancelAll is called, and a func that ends the run (which the run should defer).
+func (runs *runCancels) begin(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	runs.mutex.Lock()
+	if nil == runs.cancels {
+		runs.cancels = map[*context.CancelFunc]struct{}{}
+	}
+	runs.cancels[&cancel] = struct{}{}
+	runs.mutex.Unlock()
+
+	return ctx, func() {
+		runs.mutex.Lock()
+		delete(runs.cancels, &cancel)
+		runs.mutex.Unlock()
+
+		cancel()
+	}
+}
+
+
+// cancelAll cancels the contexts of the current runs.
+func (runs *runCancels) cancelAll() {
+	runs.mutex.Lock()
+	defer runs.mutex.Unlock()
+
+	for cancel := range runs.cancels {
+		(*cancel)()
+	}
+}
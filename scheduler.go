@@ -0,0 +1,301 @@
+package toil
+
+
+// scheduler decides when (queued) toiler runs get to start toiling, so that no
+// more than `max` toiler runs are toiling at the same time.
+//
+// Runs waiting to start are queued by their toiler's priority (see Priority).
+// Higher priority runs are served first, but with weighted-fair (stride) scheduling
+// across the priority classes, so that lower priority runs are not starved.
+type scheduler struct {
+	max int
+
+	stoppedCh   <-chan struct{}
+	enqueueCh   chan []schedulerWaiter
+	releaseCh   chan struct{}
+	positionsCh chan struct{returnCh chan map[*registeredToiler]int}
+	exitedCh    chan struct{}
+}
+
+
+// schedulerWaiter is a toiler run waiting (in the queue) to start toiling.
+//
+// When the run may start toiling, true is sent on grantCh. If the scheduler is
+// stopped before then, false is sent on grantCh instead.
+type schedulerWaiter struct {
+	registration *registeredToiler
+	grantCh      chan bool
+}
+
+
+// newScheduler returns a scheduler that lets at most `max` toiler runs toil at
+// the same time.
+//
+// It returns nil if `max` does not limit anything.
+func newScheduler(max int, stoppedCh <-chan struct{}) *scheduler {
+	if max <= 0 {
+		return nil
+	}
+
+	scheduler := scheduler{
+		max:max,
+		stoppedCh:stoppedCh,
+		enqueueCh:make(chan []schedulerWaiter),
+		releaseCh:make(chan struct{}),
+		positionsCh:make(chan struct{returnCh chan map[*registeredToiler]int}),
+		exitedCh:make(chan struct{}),
+	}
+
+	go scheduler.animate()
+
+	return &scheduler
+}
+
+
+// enqueue queues up a run for each of the registered toilers, and returns the
+// channels that each of them will be granted (or denied) on.
+//
+// All the runs are queued up at the same time, so that they are ordered by
+// priority, rather than by which was queued up first.
+func (scheduler *scheduler) enqueue(registrations ...*registeredToiler) []<-chan bool {
+	waiters  := make([]schedulerWaiter, len(registrations))
+	grantChs := make([]<-chan bool, len(registrations))
+
+	for i,registration := range registrations {
+		grantCh := make(chan bool, 1)
+
+		waiters[i] = schedulerWaiter{
+			registration:registration,
+			grantCh:grantCh,
+		}
+		grantChs[i] = grantCh
+	}
+
+	// NOTE that once the scheduler's goroutine has exited, the scheduler has been
+	// stopped. So, all the runs are denied.
+	select {
+	case scheduler.enqueueCh <- waiters:
+	case <-scheduler.exitedCh:
+		for _,waiter := range waiters {
+			waiter.grantCh <- false
+		}
+	}
+
+	return grantChs
+}
+
+
+// release lets the scheduler know that a granted run has finished toiling.
+//
+// NOTE that the scheduler's goroutine does not exit while there are granted runs that
+// have not been released.
+func (scheduler *scheduler) release() {
+	scheduler.releaseCh <- struct{}{}
+}
+
+
+// positions returns the position in the queue (starting at 1, for next) of each
+// of the registered toilers that has a run waiting to start toiling.
+func (scheduler *scheduler) positions() map[*registeredToiler]int {
+	positionsReturnCh := make(chan map[*registeredToiler]int)
+
+	// NOTE that once the scheduler's goroutine has exited, nothing is waiting in the
+	// queue.
+	select {
+	case scheduler.positionsCh <- struct{returnCh chan map[*registeredToiler]int}{returnCh:positionsReturnCh}:
+		return <-positionsReturnCh
+	case <-scheduler.exitedCh:
+		return map[*registeredToiler]int{}
+	}
+}
+
+
+// animate is the scheduler's goroutine.
+//
+// Once the scheduler has been stopped, and all the runs it granted have been released,
+// nothing more can happen that needs the scheduler's goroutine. So, it exits, and closes
+// exitedCh.
+func (scheduler *scheduler) animate() {
+
+	defer close(scheduler.exitedCh)
+
+	queues := newPriorityQueues()
+
+	running := 0
+
+	stoppedCh := scheduler.stoppedCh
+	stopped   := false
+
+	for {
+		select {
+		case waiters := <-scheduler.enqueueCh:
+			for _,waiter := range waiters {
+				if stopped {
+					waiter.grantCh <- false
+					continue
+				}
+				queues.push(waiter)
+			}
+		case <-scheduler.releaseCh:
+			running--
+		case positionsRequest := <-scheduler.positionsCh:
+			positions := map[*registeredToiler]int{}
+
+			simulation := queues.clone()
+			for position := 1; 0 < simulation.length; position++ {
+				waiter := simulation.pop()
+				if _, ok := positions[waiter.registration]; !ok {
+					positions[waiter.registration] = position
+				}
+			}
+
+			positionsRequest.returnCh <- positions
+		case <-stoppedCh:
+			stopped   = true
+			stoppedCh = nil
+
+			for 0 < queues.length {
+				queues.pop().grantCh <- false
+			}
+		}
+
+		for running < scheduler.max && 0 < queues.length {
+			running++
+			queues.pop().grantCh <- true
+		}
+
+		if stopped && 0 == running {
+			return
+		}
+	}
+}
+
+
+// strideUnit is the stride of a priority class whose weight is 1.
+const strideUnit = 1 << 20
+
+
+// maxPriority is the highest (and -maxPriority the lowest) priority that stride tells
+// apart. Priorities beyond it are treated as it, so that no stride is 0 (which would
+// make that priority class's pass never advance, and starve all the other classes), and
+// no stride overflows.
+const maxPriority = strideUnit - 1
+
+
+// priorityQueues is a queue of waiters for each priority class, along with what
+// is needed to do weighted-fair (stride) scheduling across the priority classes.
+//
+// Each priority class has a "pass". The next waiter popped is from the (non-empty)
+// priority class with the lowest pass (with ties going to the higher priority).
+// Each time a waiter is popped from a priority class, its pass is advanced by its
+// stride. Higher priority classes have smaller strides, and thus get popped from
+// more often.
+type priorityQueues struct {
+	classes     map[int]*priorityClass
+	virtualTime uint64
+	length      int
+}
+
+
+type priorityClass struct {
+	priority int
+	pass     uint64
+	waiters  []schedulerWaiter
+}
+
+
+func newPriorityQueues() *priorityQueues {
+	queues := priorityQueues{
+		classes:map[int]*priorityClass{},
+	}
+
+	return &queues
+}
+
+
+// stride returns the stride of a priority class.
+//
+// Priority 0 has a weight of 1. Each priority above that adds 1 to the weight. And
+// each priority below that divides the weight further. (I.e., priority 1 has a weight
+// of 2, priority 2 has a weight of 3, priority -1 has a weight of 1/2, etc.)
+func stride(priority int) uint64 {
+	switch {
+	case maxPriority < priority:
+		priority = maxPriority
+	case priority < -maxPriority:
+		priority = -maxPriority
+	}
+
+	if 0 <= priority {
+		return strideUnit / uint64(priority+1)
+	}
+
+	return strideUnit * uint64(1-priority)
+}
+
+
+func (queues *priorityQueues) push(waiter schedulerWaiter) {
+	priority := waiter.registration.config.priority
+
+	class, ok := queues.classes[priority]
+	if !ok {
+		class = &priorityClass{
+			priority:priority,
+		}
+		queues.classes[priority] = class
+	}
+
+	// A priority class that was empty does not get to "catch up" for the time it
+	// was empty. (Otherwise it would starve the others until it caught up.)
+	if 0 == len(class.waiters) && class.pass < queues.virtualTime {
+		class.pass = queues.virtualTime
+	}
+
+	class.waiters = append(class.waiters, waiter)
+	queues.length++
+}
+
+
+// pop removes and returns the next waiter.
+//
+// pop must not be called when the queues are empty.
+func (queues *priorityQueues) pop() schedulerWaiter {
+	var next *priorityClass
+	for _,class := range queues.classes {
+		if 0 == len(class.waiters) {
+			continue
+		}
+
+		if nil == next || class.pass < next.pass || (class.pass == next.pass && next.priority < class.priority) {
+			next = class
+		}
+	}
+
+	waiter := next.waiters[0]
+	next.waiters = next.waiters[1:]
+	queues.length--
+
+	queues.virtualTime = next.pass
+	next.pass += stride(next.priority)
+
+	return waiter
+}
+
+
+func (queues *priorityQueues) clone() *priorityQueues {
+	clone := priorityQueues{
+		classes:map[int]*priorityClass{},
+		virtualTime:queues.virtualTime,
+		length:queues.length,
+	}
+
+	for priority,class := range queues.classes {
+		clone.classes[priority] = &priorityClass{
+			priority:class.priority,
+			pass:class.pass,
+			waiters:append([]schedulerWaiter(nil), class.waiters...),
+		}
+	}
+
+	return &clone
+}
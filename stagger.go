@@ -0,0 +1,51 @@
+package toil
+
+
+import (
+	"math/rand"
+	"time"
+)
+
+
+// StaggerStarts returns a GroupOption that staggers when the Group starts its toilers
+// toiling, rather than starting them all at the same time.
+//
+// When the Group's Toil method is called, the starts of its toilers are spread out
+// evenly over `spread`, in the order the toilers were registered. And each start
+// is delayed by a further random duration between zero and `jitter`.
+//
+// Toilers registered with the Group after its Toil method was called are only
+// delayed by the `jitter`.
+func StaggerStarts(spread time.Duration, jitter time.Duration) GroupOption {
+	return staggerStartsOption{
+		spread:spread,
+		jitter:jitter,
+	}
+}
+
+
+type staggerStartsOption struct {
+	spread time.Duration
+	jitter time.Duration
+}
+
+
+func (option staggerStartsOption) applyGroupOption(config *groupConfig) {
+	config.staggerStarts = option
+}
+
+
+// delay returns how long to delay starting toiler number `i` of `n`.
+func (option staggerStartsOption) delay(i int, n int) time.Duration {
+	var delay time.Duration
+
+	if 0 < option.spread && 1 < n {
+		delay = option.spread * time.Duration(i) / time.Duration(n)
+	}
+
+	if 0 < option.jitter {
+		delay += time.Duration(rand.Int63n(int64(option.jitter)))
+	}
+
+	return delay
+}
@@ -0,0 +1,99 @@
+package toil
+
+
+import (
+	"sync"
+	"time"
+)
+
+
+// StartRate returns a GroupOption that limits how fast the Group starts its toilers
+// toiling, so that a Group with many toilers does not stampede whatever (downstream)
+// those toilers use (such as databases).
+//
+// The limit is a token bucket: at most `n` toilers are started per `interval`, with
+// bursts of up to `burst` toilers started at once.
+//
+// The limit applies both to the toilers the Group starts when its Toil method is
+// called, and to toilers registered with the Group after that.
+func StartRate(n int, interval time.Duration, burst int) GroupOption {
+	return startRateOption{
+		n:n,
+		interval:interval,
+		burst:burst,
+	}
+}
+
+
+type startRateOption struct {
+	n        int
+	interval time.Duration
+	burst    int
+}
+
+
+func (option startRateOption) applyGroupOption(config *groupConfig) {
+	config.startRate = option
+}
+
+
+// startLimiter is a token bucket, used to limit how fast toilers are started.
+type startLimiter struct {
+	mutex    sync.Mutex
+	clock    Clock
+	perToken time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+
+// newStartLimiter returns a *startLimiter for the StartRate option.
+//
+// It returns nil if the option does not limit anything.
+func newStartLimiter(option startRateOption, clock Clock) *startLimiter {
+	if option.n <= 0 || option.interval <= 0 {
+		return nil
+	}
+
+	burst := option.burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	limiter := startLimiter{
+		perToken:option.interval / time.Duration(option.n),
+		burst:float64(burst),
+		tokens:float64(burst),
+		clock:clock,
+		last:clock.Now(),
+	}
+
+	return &limiter
+}
+
+
+// reserve takes a token from the bucket, and returns how long to wait before
+// that token can be used.
+func (limiter *startLimiter) reserve() time.Duration {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.clock.Now()
+
+	if elapsed := now.Sub(limiter.last); 0 < elapsed && 0 < limiter.perToken {
+		limiter.tokens += float64(elapsed) / float64(limiter.perToken)
+		if limiter.burst < limiter.tokens {
+			limiter.tokens = limiter.burst
+		}
+	}
+	limiter.last = now
+
+	limiter.tokens--
+
+	if 0 <= limiter.tokens {
+		return 0
+	}
+
+	return time.Duration(-limiter.tokens * float64(limiter.perToken))
+}
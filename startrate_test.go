@@ -0,0 +1,128 @@
+package toil
+
+
+import (
+	"testing"
+
+	"sync"
+	"time"
+)
+
+
+// startTimes makes a group (with the options) of `n` toilers toil, and returns
+// how long after the group's Toil method was called each toiler started toiling.
+func startTimes(n int, options ...GroupOption) []time.Duration {
+
+	var mutex sync.Mutex
+	var durations []time.Duration
+
+	group := NewGroup(options...)
+
+	var begin time.Time
+
+	for i:=0; i<n; i++ {
+		group.Register( ToilerFunc(func(){
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			durations = append(durations, time.Since(begin))
+		}) )
+	}
+
+	begin = time.Now()
+	group.Toil()
+
+	return durations
+}
+
+
+func TestStartRate(t *testing.T) {
+
+	const interval = 20 * time.Millisecond
+
+	durations := startTimes(5, StartRate(1, interval, 2))
+
+	if expected, actual := 5, len(durations); expected != actual {
+		t.Errorf("Expected %d toilers to have toiled, but actually %d did.", expected, actual)
+		return
+	}
+
+	var numImmediate int
+	var latest time.Duration
+	for _,duration := range durations {
+		if duration < interval/2 {
+			numImmediate++
+		}
+		if latest < duration {
+			latest = duration
+		}
+	}
+
+	if expected, actual := 2, numImmediate; expected != actual {
+		t.Errorf("Expected %d toilers (the burst) to start immediately, but actually %d did: %v", expected, actual, durations)
+	}
+
+	if minimum, actual := 3*interval - interval/2, latest; actual < minimum {
+		t.Errorf("Expected the last toiler to start after at least %v, but actually started after %v.", minimum, actual)
+	}
+}
+
+
+func TestStartRateNewStartLimiter(t *testing.T) {
+
+	if limiter := newStartLimiter(startRateOption{}, realClock{}); nil != limiter {
+		t.Errorf("Expected no start limiter for the zero value option, but actually got: %#v", limiter)
+	}
+
+	limiter := newStartLimiter(startRateOption{n:10, interval:time.Second, burst:3}, realClock{})
+	if nil == limiter {
+		t.Errorf("Expected a start limiter, but actually got nil.")
+		return
+	}
+
+	for i:=0; i<3; i++ {
+		if expected, actual := time.Duration(0), limiter.reserve(); expected != actual {
+			t.Errorf("For reservation #%d, expected to wait %v, but actually to wait %v.", i, expected, actual)
+		}
+	}
+
+	if minimum, actual := 90*time.Millisecond, limiter.reserve(); actual < minimum {
+		t.Errorf("Expected to wait at least %v, but actually to wait %v.", minimum, actual)
+	}
+}
+
+
+func TestStaggerStarts(t *testing.T) {
+
+	const spread = 50 * time.Millisecond
+
+	durations := startTimes(5, StaggerStarts(spread, 0))
+
+	if expected, actual := 5, len(durations); expected != actual {
+		t.Errorf("Expected %d toilers to have toiled, but actually %d did.", expected, actual)
+		return
+	}
+
+	for i,duration := range durations {
+		if minimum, actual := spread * time.Duration(i) / 5, duration; actual < minimum {
+			t.Errorf("For start #%d, expected to start after at least %v, but actually started after %v.", i, minimum, actual)
+		}
+	}
+}
+
+
+func TestStaggerStartsDelay(t *testing.T) {
+
+	option := staggerStartsOption{spread:100*time.Millisecond, jitter:10*time.Millisecond}
+
+	for i:=0; i<10; i++ {
+		delay := option.delay(i, 10)
+
+		if minimum, actual := time.Duration(i)*10*time.Millisecond, delay; actual < minimum {
+			t.Errorf("For toiler #%d, expected delay to be at least %v, but actually was %v.", i, minimum, actual)
+		}
+		if maximum, actual := time.Duration(i+1)*10*time.Millisecond, delay; maximum <= actual {
+			t.Errorf("For toiler #%d, expected delay to be less than %v, but actually was %v.", i, maximum, actual)
+		}
+	}
+}
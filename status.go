@@ -0,0 +1,55 @@
+package toil
+
+
+import (
+	"time"
+)
+
+
+// ToilerStatus is the status of a toiler registered with a Group. (See the Group's
+// Status method.)
+type ToilerStatus struct {
+	// Name is the name of the toiler. (See Name.)
+	Name string
+
+	// Pool is the name of the pool the toiler is in. (See Pool and InPool.)
+	// It is "" for the Group's default pool.
+	Pool string
+
+	Toiler Toiler
+
+	// Toiling is whether the toiler is currently toiling.
+	Toiling bool
+
+	// Runs is the number of times the toiler has been started toiling.
+	Runs int
+
+	// Panics is the number of times the toiler's runs have panic()ed.
+	Panics int
+
+	// Restarts is the number of times the toiler has been restarted.
+	// (See RestartPolicy and WatchdogRestart.)
+	Restarts int
+
+	// TimedOut is the number of times the toiler's runs have timed out.
+	// (See Timeout.)
+	TimedOut int
+
+	// LastHeartbeat is when the toiler's current run last beat its heartbeat.
+	// It is the zero time if the toiler is not toiling, or if its current run
+	// did not get its heartbeat. (See Heartbeat.)
+	LastHeartbeat time.Time
+
+	// QueuePosition is the toiler's position in the queue of toilers waiting
+	// to start toiling (starting at 1, for next), when the Group has a
+	// MaxConcurrency. It is 0 if the toiler is not waiting to start toiling.
+	// (See MaxConcurrency and Priority.)
+	QueuePosition int
+
+	// Paused is whether the toiler is paused. (See Pause.)
+	Paused bool
+
+	// Hung is whether the Group's Watchdog has flagged the toiler's current run
+	// as hung. (See Watchdog.)
+	Hung bool
+}
@@ -0,0 +1,17 @@
+package toil
+
+
+// Stopper is an interface that wraps the Stop method.
+//
+// If a toiler is also a Stopper, then its Stop method will be called when the
+// Group it is registered with is stopped. (For example, by calling the Group's
+// Stop method, or due to the StopGroup PanicPolicy.)
+//
+// NOTE that Stop is not called when the toiler is paused, or restarted to reload. (Those
+// only cancel the context of the toiler's run, if it is a ContextToiler.) So, the Stop
+// method can treat being called as the toiler being stopped for good.
+//
+// The Stop method should make the toiler's (blocking) Toil method return gracefully.
+type Stopper interface {
+	Stop()
+}
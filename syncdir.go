@@ -0,0 +1,21 @@
+//go:build !windows
+
+package toil
+
+
+import (
+	"os"
+)
+
+
+// syncDir syncs a directory to disk. (For example, so that a file renamed in it is
+// not lost if the operating system crashes.)
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
@@ -0,0 +1,8 @@
+package toil
+
+
+// syncDir does nothing on Windows, where a directory cannot be synced (as it cannot be
+// opened for writing).
+func syncDir(dir string) error {
+	return nil
+}
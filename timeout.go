@@ -0,0 +1,61 @@
+package toil
+
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+
+// Timeout returns a RegisterOption that limits how long each run of the toiler
+// (i.e., each call to its Toil or ToilContext method) may take.
+//
+// If the toiler is a ContextToiler, then it is given a context with a deadline
+// `timeout` from when it started toiling.
+//
+// If the toiler has not returned by then, then the Group marks it as timed out:
+// the Group stops waiting on it, calls its TimedOutNotice method (if it has one),
+// and applies its PanicPolicy, as if the toiler had panic()ed with a *TimeoutError.
+//
+// NOTE that a run that timed out keeps its place in the MaxConcurrency limit (of its
+// pool) until it actually returns. So, if a toiler that is not a ContextToiler (and
+// so cannot be made to return) keeps timing out, then its restarts wait for its hung
+// runs to return, rather than going past the limit.
+func Timeout(timeout time.Duration) RegisterOption {
+	return timeoutOption{
+		timeout:timeout,
+	}
+}
+
+
+type timeoutOption struct {
+	timeout time.Duration
+}
+
+
+func (option timeoutOption) applyRegisterOption(config *registerConfig) {
+	config.timeout = option.timeout
+}
+
+
+// TimeoutError is the error that describes a toiler's run timing out. (See Timeout.)
+//
+// When a toiler's run times out, the Group applies its PanicPolicy with a *PanicError
+// whose Value is a *TimeoutError.
+type TimeoutError struct {
+	Toiler  Toiler
+	Timeout time.Duration
+}
+
+
+// Error is part of the error interface.
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("toil: toiler %T timed out after %v", err.Toiler, err.Timeout)
+}
+
+
+// Unwrap returns context.DeadlineExceeded.
+func (err *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
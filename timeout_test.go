@@ -0,0 +1,266 @@
+package toil
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil/toiltest"
+
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+
+type timedOutRecorder struct {
+	blockCh    chan struct{}
+	timedOutCh chan struct{}
+}
+
+func newTimedOutRecorder() *timedOutRecorder {
+	toiler := timedOutRecorder{
+		blockCh:make(chan struct{}),
+		timedOutCh:make(chan struct{}, 1),
+	}
+
+	return &toiler
+}
+
+func (toiler *timedOutRecorder) Toil() {
+	<-toiler.blockCh
+}
+
+func (toiler *timedOutRecorder) TimedOutNotice() {
+	toiler.timedOutCh <- struct{}{}
+}
+
+
+func TestTimeoutHung(t *testing.T) {
+
+	toiler := newTimedOutRecorder()
+	defer close(toiler.blockCh)
+
+	group := NewGroup(Isolate, SynchronousNotices(0))
+	group.RegisterWith(toiler, Timeout(20*time.Millisecond))
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	select {
+	case <-toiler.timedOutCh:
+	default:
+		t.Errorf("Expected TimedOutNotice() to have been called, but it was not.")
+	}
+
+	panics := group.Panics()
+	if expected, actual := 1, len(panics); expected != actual {
+		t.Errorf("Expected number of recorded panics to be %d, but actually was %d.", expected, actual)
+		return
+	}
+
+	timeoutError, ok := panics[0].Value.(*TimeoutError)
+	if !ok {
+		t.Errorf("Expected panic value to be a *TimeoutError, but actually was: (%T) %v", panics[0].Value, panics[0].Value)
+		return
+	}
+	if expected, actual := 20*time.Millisecond, timeoutError.Timeout; expected != actual {
+		t.Errorf("Expected timeout to be %v, but actually was %v.", expected, actual)
+	}
+	if !errors.Is(panics[0], context.DeadlineExceeded) {
+		t.Errorf("Expected error to be a context.DeadlineExceeded, but it was not: %v", panics[0])
+	}
+}
+
+
+func TestTimeoutContext(t *testing.T) {
+
+	deadlineCh := make(chan bool, 1)
+
+	group := NewGroup(Isolate)
+	group.RegisterWith(ContextToilerFunc(func(ctx context.Context){
+		_, ok := ctx.Deadline()
+		deadlineCh <- ok
+
+		<-ctx.Done()
+	}), Timeout(20*time.Millisecond))
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	if !<-deadlineCh {
+		t.Errorf("Expected the context to have a deadline, but it did not.")
+	}
+
+	panics := group.Panics()
+	if expected, actual := 1, len(panics); expected != actual {
+		t.Errorf("Expected number of recorded panics to be %d, but actually was %d.", expected, actual)
+		return
+	}
+	if _, ok := panics[0].Value.(*TimeoutError); !ok {
+		t.Errorf("Expected panic value to be a *TimeoutError, but actually was: (%T) %v", panics[0].Value, panics[0].Value)
+	}
+}
+
+
+func TestTimeoutPropagate(t *testing.T) {
+
+	toiler := newTimedOutRecorder()
+	defer close(toiler.blockCh)
+
+	group := NewGroup()
+	group.RegisterWith(toiler, Timeout(10*time.Millisecond))
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if returned {
+		t.Errorf("Expected Toil() to panic(), but it returned.")
+		return
+	}
+	if _, ok := panicValue.(*TimeoutError); !ok {
+		t.Errorf("Expected panic value to be a *TimeoutError, but actually was: (%T) %v", panicValue, panicValue)
+	}
+}
+
+
+func TestTimeoutNotExceeded(t *testing.T) {
+
+	group := NewGroup(Isolate)
+	group.RegisterWith(ToilerFunc(func(){}), Timeout(time.Second))
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	if expected, actual := 0, len(group.Panics()); expected != actual {
+		t.Errorf("Expected number of recorded panics to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestTimeoutFakeClock(t *testing.T) {
+
+	clock := toiltest.NewFakeClock(time.Date(2016, 10, 19, 0, 0, 0, 0, time.UTC))
+
+	toiler := newTimedOutRecorder()
+	defer close(toiler.blockCh)
+
+	group := NewGroup(Isolate, SynchronousNotices(0), WithClock(clock))
+	group.RegisterWith(toiler, Timeout(time.Hour))
+
+	doneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(doneCh)
+	}()
+
+	// The timeout's timer, and the timer watching for it.
+	clock.BlockUntilWaiters(2)
+
+	if expected, actual := clock.Now().Add(time.Hour), clock.Deadlines()[0]; !expected.Equal(actual) {
+		t.Errorf("Expected the deadline to be %v, but actually was %v.", expected, actual)
+	}
+
+	clock.Advance(time.Hour - time.Nanosecond)
+
+	select {
+	case <-toiler.timedOutCh:
+		t.Fatalf("Did not expect TimedOutNotice() to have been called before the timeout, but it was.")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.Advance(time.Nanosecond)
+
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected Toil() to return, but it did not.")
+	}
+
+	select {
+	case <-toiler.timedOutCh:
+	default:
+		t.Errorf("Expected TimedOutNotice() to have been called, but it was not.")
+	}
+
+	panics := group.Panics()
+	if expected, actual := 1, len(panics); expected != actual {
+		t.Fatalf("Expected number of recorded panics to be %d, but actually was %d.", expected, actual)
+	}
+
+	var timeoutError *TimeoutError
+	if !errors.As(panics[0], &timeoutError) {
+		t.Errorf("Expected the panic to be a *TimeoutError, but actually was: %#v", panics[0].Value)
+	}
+
+	if expected, actual := clock.Now(), panics[0].Time; !expected.Equal(actual) {
+		t.Errorf("Expected the time of the panic to be %v, but actually was %v.", expected, actual)
+	}
+}
+
+
+func TestTimeoutKeepsSlotUntilReturned(t *testing.T) {
+
+	releaseCh := make(chan struct{})
+
+	var mutex sync.Mutex
+	var running, maxRunning, numRuns int
+
+	group := NewGroup(MaxConcurrency(1), RestartOnPanic, RestartBackoff(0, 0))
+	group.RegisterWith(ToilerFunc(func(){
+		mutex.Lock()
+		running++
+		numRuns++
+		if maxRunning < running {
+			maxRunning = running
+		}
+		mutex.Unlock()
+
+		<-releaseCh
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+	}), Timeout(5*time.Millisecond))
+
+	toilDoneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(toilDoneCh)
+	}()
+
+	// The first run hangs, and times out. Its restart must wait for it to return.
+	if !eventually(time.Second, func() bool { return 0 < group.Status()[0].TimedOut }) {
+		t.Fatalf("Expected the toiler's run to time out, but it did not.")
+	}
+	time.Sleep(50*time.Millisecond)
+
+	mutex.Lock()
+	if expected, actual := 1, numRuns; expected != actual {
+		t.Errorf("Expected the number of runs to be %d (while the hung run has not returned), but actually was %d.", expected, actual)
+	}
+	mutex.Unlock()
+
+	group.Stop()
+	close(releaseCh)
+
+	select {
+	case <-toilDoneCh:
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected Toil() to return, but it did not.")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if expected, actual := 1, maxRunning; expected != actual {
+		t.Errorf("Expected at most %d run to be toiling at the same time, but actually was %d.", expected, actual)
+	}
+}
@@ -0,0 +1,336 @@
+/*
+Package toilchaos provides fault injection (i.e., "chaos") for toilers, for resilience testing.
+
+A Chaos wraps toilers so that their runs (pseudo) randomly panic(), wait (i.e., have latency),
+return early, or ignore being cancelled. This exercises a toil.Group's PanicPolicy, RestartPolicy,
+Timeout(s), Watchdog, etc, and whatever alerts are hooked up to them.
+
+For example:
+
+	chaos := toilchaos.New(
+		toilchaos.Seed(42),
+		toilchaos.Panics(0.05),
+		toilchaos.Latency(0.2, 100*time.Millisecond),
+		toilchaos.EarlyReturns(0.05),
+	)
+	
+	group := toil.NewGroup(toil.Isolate, toil.RestartAlways)
+	group.Register(chaos.Wrap(toiler))
+
+The faults injected are reproducible from the seed. (See Seed.)
+*/
+package toilchaos
+
+
+import (
+	"github.com/reiver/go-toil"
+
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+
+// Chaos wraps toilers, to inject faults into their runs. (See New and Wrap.)
+type Chaos struct {
+	config config
+
+	mutex    sync.Mutex
+	numWraps int64
+	stats    Stats
+}
+
+
+// Stats are the numbers of each kind of fault a Chaos injected.
+type Stats struct {
+	Runs                 int
+	Panics               int
+	Latencies            int
+	EarlyReturns         int
+	IgnoredCancellations int
+}
+
+
+// New returns a Chaos that injects the faults configured by `options`.
+func New(options ...Option) *Chaos {
+	chaos := Chaos{
+		config:newConfig(options...),
+	}
+
+	return &chaos
+}
+
+
+// Stats returns the numbers of each kind of fault injected (so far).
+func (chaos *Chaos) Stats() Stats {
+	chaos.mutex.Lock()
+	defer chaos.mutex.Unlock()
+
+	return chaos.stats
+}
+
+
+// Wrap returns a toiler that wraps `toiler`, and injects faults into its runs.
+//
+// The returned toiler is also a toil.Stopper, and has the notice methods (such as
+// ReturnedNotice and PanickedNotice), which it forwards to `toiler` (if it has them).
+// If `toiler` is a toil.Reloader, then so is the returned toiler, and it forwards its
+// Reload method to `toiler`.
+func (chaos *Chaos) Wrap(toiler toil.Toiler) toil.ContextToiler {
+	chaos.mutex.Lock()
+	chaos.numWraps++
+	seed := chaos.config.seed + chaos.numWraps
+	chaos.mutex.Unlock()
+
+	stopped, stop := context.WithCancel(context.Background())
+
+	wrapped := chaosToiler{
+		chaos:chaos,
+		toiler:toiler,
+		randomness:rand.New(rand.NewSource(seed)),
+		stopped:stopped,
+		stop:stop,
+	}
+
+	// NOTE that a toiler that is not a toil.Reloader must not be wrapped in one, since
+	// then the Group would reload it (rather than restart it) when asked to reload.
+	if _, ok := toiler.(toil.Reloader); ok {
+		return &chaosReloader{&wrapped}
+	}
+
+	return &wrapped
+}
+
+
+// count updates the stats.
+func (chaos *Chaos) count(fn func(*Stats)) {
+	chaos.mutex.Lock()
+	defer chaos.mutex.Unlock()
+
+	fn(&chaos.stats)
+}
+
+
+// InjectedPanic is the panic value of an injected panic. (See Panics.)
+type InjectedPanic struct {
+	Toiler toil.Toiler
+	Run    int
+}
+
+
+// Error is part of the error interface.
+func (err *InjectedPanic) Error() string {
+	return fmt.Sprintf("toilchaos: injected panic into run %d of toiler %T", err.Run, err.Toiler)
+}
+
+
+// chaosToiler is a toiler that a Chaos wrapped.
+type chaosToiler struct {
+	chaos  *Chaos
+	toiler toil.Toiler
+
+	mutex      sync.Mutex
+	randomness *rand.Rand
+	numRuns    int
+	ignoring   int                // The number of current runs that are ignoring cancellation.
+	stopped    context.Context    // NOTE that this is cancelled (and replaced) whenever the Stop method is called.
+	stop       context.CancelFunc // Cancels stopped.
+}
+
+
+// faults are the faults to inject into a run.
+type faults struct {
+	run                int
+	stopped            context.Context // Done when the Stop method is called (after the run began).
+	latency            time.Duration
+	ignoreCancellation bool
+	earlyReturn        bool
+	panic              bool
+}
+
+
+// decide decides which faults to inject into the next run.
+//
+// NOTE that it always draws the same (pseudo) random numbers, in the same order, whatever
+// the probabilities are, so that the faults are reproducible from the seed.
+func (toiler *chaosToiler) decide() faults {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	config := toiler.chaos.config
+
+	toiler.numRuns++
+
+	var f faults
+	f.run     = toiler.numRuns
+	f.stopped = toiler.stopped
+
+	latency  := toiler.randomness.Float64() < config.latencyProbability
+	fraction := toiler.randomness.Float64()
+	if latency && 0 < config.maxLatency {
+		f.latency = time.Duration(fraction * float64(config.maxLatency))
+	}
+
+	f.ignoreCancellation = toiler.randomness.Float64() < config.ignoreCancellationProbability
+	f.earlyReturn        = toiler.randomness.Float64() < config.earlyReturnProbability
+	f.panic              = toiler.randomness.Float64() < config.panicProbability
+
+	if f.ignoreCancellation {
+		toiler.ignoring++
+	}
+
+	toiler.chaos.count(func(stats *Stats){
+		stats.Runs++
+		if latency {
+			stats.Latencies++
+		}
+		if f.ignoreCancellation {
+			stats.IgnoredCancellations++
+		}
+		if f.panic {
+			stats.Panics++
+		} else if f.earlyReturn {
+			stats.EarlyReturns++
+		}
+	})
+
+	return f
+}
+
+
+// Toil is part of the toil.Toiler interface.
+func (toiler *chaosToiler) Toil() {
+	toiler.ToilContext(context.Background())
+}
+
+
+// ToilContext is part of the toil.ContextToiler interface.
+func (toiler *chaosToiler) ToilContext(ctx context.Context) {
+	f := toiler.decide()
+
+	if f.ignoreCancellation {
+		ctx = context.WithoutCancel(ctx)
+
+		defer func() {
+			toiler.mutex.Lock()
+			toiler.ignoring--
+			toiler.mutex.Unlock()
+		}()
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+
+		stopCancelling := context.AfterFunc(f.stopped, cancel)
+		defer stopCancelling()
+	}
+
+	if 0 < f.latency {
+		timer := time.NewTimer(f.latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	if f.panic {
+		panic(&InjectedPanic{
+			Toiler:toiler.toiler,
+			Run:f.run,
+		})
+	}
+
+	if f.earlyReturn {
+		return
+	}
+
+	if contextToiler, ok := toiler.toiler.(toil.ContextToiler); ok {
+		contextToiler.ToilContext(ctx)
+		return
+	}
+
+	toiler.toiler.Toil()
+}
+
+
+// Stop is part of the toil.Stopper interface.
+//
+// It cancels the contexts of the current runs that are not ignoring cancellation. And
+// it calls the Stop method of the wrapped toiler (if it has one), unless a current run
+// is ignoring cancellation. (Since the wrapped toiler's Stop method would stop all its
+// runs, including those.)
+//
+// NOTE that this means that, while a run is ignoring cancellation, the other runs of a
+// wrapped toiler that is not a toil.ContextToiler are not stopped.
+func (toiler *chaosToiler) Stop() {
+	toiler.mutex.Lock()
+	ignoring := 0 < toiler.ignoring
+
+	toiler.stop()
+	toiler.stopped, toiler.stop = context.WithCancel(context.Background())
+	toiler.mutex.Unlock()
+
+	if ignoring {
+		return
+	}
+
+	if stopper, ok := toiler.toiler.(toil.Stopper); ok {
+		stopper.Stop()
+	}
+}
+
+
+// chaosReloader is a chaosToiler whose wrapped toiler is a toil.Reloader.
+type chaosReloader struct {
+	*chaosToiler
+}
+
+
+// Reload is part of the toil.Reloader interface. It forwards to the wrapped toiler.
+func (toiler *chaosReloader) Reload(ctx context.Context) error {
+	return toiler.toiler.(toil.Reloader).Reload(ctx)
+}
+
+
+// ReturnedNotice forwards the notice to the wrapped toiler (if it has the method).
+func (toiler *chaosToiler) ReturnedNotice() {
+	if notifiable, ok := toiler.toiler.(interface{ReturnedNotice()}); ok {
+		notifiable.ReturnedNotice()
+	}
+}
+
+
+// PanickedNotice forwards the notice to the wrapped toiler (if it has the method).
+func (toiler *chaosToiler) PanickedNotice(panicValue interface{}) {
+	if notifiable, ok := toiler.toiler.(interface{PanickedNotice(interface{})}); ok {
+		notifiable.PanickedNotice(panicValue)
+	}
+}
+
+
+// PanickedErrorNotice forwards the notice to the wrapped toiler (if it has the method).
+func (toiler *chaosToiler) PanickedErrorNotice(panicError *toil.PanicError) {
+	if notifiable, ok := toiler.toiler.(interface{PanickedErrorNotice(*toil.PanicError)}); ok {
+		notifiable.PanickedErrorNotice(panicError)
+	}
+}
+
+
+// RecoveredNotice forwards the notice to the wrapped toiler (if it has the method).
+func (toiler *chaosToiler) RecoveredNotice(panicValue interface{}) {
+	if notifiable, ok := toiler.toiler.(interface{RecoveredNotice(interface{})}); ok {
+		notifiable.RecoveredNotice(panicValue)
+	}
+}
+
+
+// TimedOutNotice forwards the notice to the wrapped toiler (if it has the method).
+func (toiler *chaosToiler) TimedOutNotice() {
+	if notifiable, ok := toiler.toiler.(interface{TimedOutNotice()}); ok {
+		notifiable.TimedOutNotice()
+	}
+}
@@ -0,0 +1,304 @@
+package toilchaos
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil"
+
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+
+// outcomes makes a toiler wrapped by a new Chaos (with `options`) do `n` runs, and
+// returns what happened in each of them.
+func outcomes(n int, options ...Option) []string {
+	chaos := New(options...)
+
+	toiler := chaos.Wrap(toil.ToilerFunc(func(){}))
+
+	var results []string
+	for i:=0; i<n; i++ {
+		func() {
+			defer func() {
+				if panicValue := recover(); nil != panicValue {
+					results = append(results, "panicked")
+				}
+			}()
+
+			toiler.Toil()
+			results = append(results, "returned")
+		}()
+	}
+
+	return results
+}
+
+
+func TestSeedReproducible(t *testing.T) {
+
+	const n = 100
+
+	first  := outcomes(n, Seed(42), Panics(0.5))
+	second := outcomes(n, Seed(42), Panics(0.5))
+
+	if expected, actual := n, len(first); expected != actual {
+		t.Fatalf("Expected the number of outcomes to be %d, but actually was %d.", expected, actual)
+	}
+
+	numPanicked := 0
+	for i := range first {
+		if expected, actual := first[i], second[i]; expected != actual {
+			t.Errorf("For run #%d, expected the outcome to be %q, but actually was %q.", i, expected, actual)
+		}
+		if "panicked" == first[i] {
+			numPanicked++
+		}
+	}
+
+	if 0 == numPanicked || n == numPanicked {
+		t.Errorf("Expected some (but not all) runs to have panicked, but actually %d of %d did.", numPanicked, n)
+	}
+}
+
+
+func TestNoFaults(t *testing.T) {
+
+	for i, outcome := range outcomes(20, Seed(7)) {
+		if expected, actual := "returned", outcome; expected != actual {
+			t.Errorf("For run #%d, expected the outcome to be %q, but actually was %q.", i, expected, actual)
+		}
+	}
+}
+
+
+func TestEarlyReturns(t *testing.T) {
+
+	toiled := 0
+
+	chaos := New(EarlyReturns(1))
+	toiler := chaos.Wrap(toil.ToilerFunc(func(){ toiled++ }))
+
+	toiler.Toil()
+
+	if expected, actual := 0, toiled; expected != actual {
+		t.Errorf("Expected the wrapped toiler to have toiled %d times, but actually was %d.", expected, actual)
+	}
+
+	if expected, actual := (Stats{Runs:1, EarlyReturns:1}), chaos.Stats(); expected != actual {
+		t.Errorf("Expected the stats to be %#v, but actually were %#v.", expected, actual)
+	}
+}
+
+
+func TestIgnoredCancellations(t *testing.T) {
+
+	var toiledCtx context.Context
+
+	chaos := New(IgnoredCancellations(1))
+	toiler := chaos.Wrap(toil.ContextToilerFunc(func(ctx context.Context){
+		toiledCtx = ctx
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	toiler.ToilContext(ctx)
+
+	if nil == toiledCtx {
+		t.Fatalf("Expected the wrapped toiler to have toiled, but it did not.")
+	}
+	if err := toiledCtx.Err(); nil != err {
+		t.Errorf("Expected the wrapped toiler's context to not be cancelled, but actually was: %v", err)
+	}
+
+	stats := chaos.Stats()
+	if expected, actual := 1, stats.IgnoredCancellations; expected != actual {
+		t.Errorf("Expected the number of ignored cancellations to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestChaosInGroup(t *testing.T) {
+
+	var toiledCh = make(chan struct{}, 1)
+
+	chaos := New(Seed(1), Panics(0.5))
+
+	group := toil.NewGroup(toil.Isolate, toil.RestartOnPanic)
+	group.Register( chaos.Wrap(toil.ToilerFunc(func(){
+		toiledCh <- struct{}{}
+	})) )
+
+	doneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected Toil() to return (after the toiler eventually did not panic), but it did not.")
+	}
+
+	select {
+	case <-toiledCh:
+	default:
+		t.Errorf("Expected the wrapped toiler to have toiled, but it did not.")
+	}
+
+	stats := chaos.Stats()
+	if expected, actual := stats.Panics+1, stats.Runs; expected != actual {
+		t.Errorf("Expected the number of runs to be %d, but actually was %d.", expected, actual)
+	}
+	if expected, actual := stats.Panics, group.Status()[0].Restarts; expected != actual {
+		t.Errorf("Expected the number of restarts to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+// stoppableToiler is a toiler (for the tests) whose runs toil until their context is
+// done, or releaseCh is closed, and that counts the calls to its Stop method.
+type stoppableToiler struct {
+	startedCh chan struct{}
+	releaseCh chan struct{}
+	numStops  int32
+}
+
+
+func (toiler *stoppableToiler) Toil() {
+	toiler.ToilContext(context.Background())
+}
+
+
+func (toiler *stoppableToiler) ToilContext(ctx context.Context) {
+	toiler.startedCh <- struct{}{}
+
+	select {
+	case <-ctx.Done():
+	case <-toiler.releaseCh:
+	}
+}
+
+
+func (toiler *stoppableToiler) Stop() {
+	atomic.AddInt32(&toiler.numStops, 1)
+}
+
+
+func TestStopHeldBackOnlyForIgnoringRuns(t *testing.T) {
+
+	inner := stoppableToiler{
+		startedCh:make(chan struct{}),
+		releaseCh:make(chan struct{}),
+	}
+	defer close(inner.releaseCh)
+
+	chaos := New(IgnoredCancellations(1))
+	toiler := chaos.Wrap(&inner)
+
+	ignoringDoneCh := make(chan struct{})
+	go func() {
+		toiler.Toil()
+		close(ignoringDoneCh)
+	}()
+	<-inner.startedCh
+
+	// NOTE that the next run does not ignore cancellation.
+	chaos.config.ignoreCancellationProbability = 0
+
+	doneCh := make(chan struct{})
+	go func() {
+		toiler.Toil()
+		close(doneCh)
+	}()
+	<-inner.startedCh
+
+	toiler.(toil.Stopper).Stop()
+
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the run that is not ignoring cancellation to be stopped, but it was not.")
+	}
+
+	select {
+	case <-ignoringDoneCh:
+		t.Errorf("Expected the run that is ignoring cancellation to not be stopped, but it was.")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// NOTE that the wrapped toiler's Stop method would have stopped the ignoring run too.
+	if expected, actual := int32(0), atomic.LoadInt32(&inner.numStops); expected != actual {
+		t.Errorf("Expected the wrapped toiler's Stop method to have been called %d times, but actually was %d.", expected, actual)
+	}
+
+	// A run that begins after the Stop is not stopped by it.
+	laterDoneCh := make(chan struct{})
+	go func() {
+		toiler.Toil()
+		close(laterDoneCh)
+	}()
+	<-inner.startedCh
+
+	select {
+	case <-laterDoneCh:
+		t.Errorf("Expected the later run to not be stopped by the earlier Stop, but it was.")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	inner.releaseCh <- struct{}{}
+	inner.releaseCh <- struct{}{}
+	<-ignoringDoneCh
+	<-laterDoneCh
+
+	// With no run ignoring cancellation, the Stop is forwarded to the wrapped toiler.
+	toiler.(toil.Stopper).Stop()
+
+	if expected, actual := int32(1), atomic.LoadInt32(&inner.numStops); expected != actual {
+		t.Errorf("Expected the wrapped toiler's Stop method to have been called %d times, but actually was %d.", expected, actual)
+	}
+}
+
+
+// reloadableToiler is a toiler (for the tests) that counts the calls to its Reload method.
+type reloadableToiler struct {
+	toil.ToilerFunc
+	numReloads int
+}
+
+
+func (toiler *reloadableToiler) Reload(ctx context.Context) error {
+	toiler.numReloads++
+	return nil
+}
+
+
+func TestReload(t *testing.T) {
+
+	chaos := New()
+
+	inner := reloadableToiler{
+		ToilerFunc:func(){},
+	}
+
+	reloader, ok := chaos.Wrap(&inner).(toil.Reloader)
+	if !ok {
+		t.Fatalf("Expected the wrapped Reloader to be a Reloader, but it was not.")
+	}
+	if err := reloader.Reload(context.Background()); nil != err {
+		t.Errorf("Did not expect an error, but actually got one: (%T) %v", err, err)
+	}
+	if expected, actual := 1, inner.numReloads; expected != actual {
+		t.Errorf("Expected the wrapped toiler's Reload method to have been called %d times, but actually was %d.", expected, actual)
+	}
+
+	// NOTE that a toiler that is not a Reloader must not become one, so that the Group
+	// restarts it (rather than reloads it).
+	if _, ok := chaos.Wrap(toil.ToilerFunc(func(){})).(toil.Reloader); ok {
+		t.Errorf("Expected the wrapped toiler (that is not a Reloader) to not be a Reloader, but it was.")
+	}
+}
@@ -0,0 +1,137 @@
+package toilchaos
+
+
+import (
+	"time"
+)
+
+
+// Option is an option that can be passed to New, to configure the faults the Chaos
+// that it returns injects.
+//
+// For example:
+//
+//	chaos := toilchaos.New(toilchaos.Seed(42), toilchaos.Panics(0.1))
+type Option interface {
+	applyOption(*config)
+}
+
+
+// config is the configuration of a Chaos, as built up from the Option(s) passed to New.
+type config struct {
+	seed int64
+
+	panicProbability float64
+
+	latencyProbability float64
+	maxLatency         time.Duration
+
+	earlyReturnProbability float64
+
+	ignoreCancellationProbability float64
+}
+
+
+func newConfig(options ...Option) config {
+	var config config
+
+	for _,option := range options {
+		if nil == option {
+			continue
+		}
+		option.applyOption(&config)
+	}
+
+	return config
+}
+
+
+// Seed returns an Option that seeds the (pseudo) random decisions of which faults
+// to inject, so that they are reproducible.
+//
+// Each wrapped toiler gets its own (pseudo) random source, derived from the seed and the
+// order in which it was wrapped. So, as long as the toilers are wrapped in the same
+// order, the same faults are injected into the same runs of the same toilers.
+//
+// The default seed is 0.
+func Seed(seed int64) Option {
+	return seedOption(seed)
+}
+
+
+type seedOption int64
+
+
+func (option seedOption) applyOption(config *config) {
+	config.seed = int64(option)
+}
+
+
+// Panics returns an Option that makes each run of a wrapped toiler panic() (instead
+// of toiling) with probability `p`.
+//
+// The panic value is an *InjectedPanic.
+func Panics(p float64) Option {
+	return panicsOption(p)
+}
+
+
+type panicsOption float64
+
+
+func (option panicsOption) applyOption(config *config) {
+	config.panicProbability = float64(option)
+}
+
+
+// Latency returns an Option that makes each run of a wrapped toiler wait (before
+// toiling) for a (pseudo) random duration of up to `max`, with probability `p`.
+func Latency(p float64, max time.Duration) Option {
+	return latencyOption{
+		p:p,
+		max:max,
+	}
+}
+
+
+type latencyOption struct {
+	p   float64
+	max time.Duration
+}
+
+
+func (option latencyOption) applyOption(config *config) {
+	config.latencyProbability = option.p
+	config.maxLatency         = option.max
+}
+
+
+// EarlyReturns returns an Option that makes each run of a wrapped toiler return
+// (gracefully, without toiling) with probability `p`.
+func EarlyReturns(p float64) Option {
+	return earlyReturnsOption(p)
+}
+
+
+type earlyReturnsOption float64
+
+
+func (option earlyReturnsOption) applyOption(config *config) {
+	config.earlyReturnProbability = float64(option)
+}
+
+
+// IgnoredCancellations returns an Option that makes each run of a wrapped toiler
+// ignore being cancelled with probability `p`. I.e., its context is not cancelled,
+// and its Stop method is not called, when the Group cancels or stops it.
+func IgnoredCancellations(p float64) Option {
+	return ignoredCancellationsOption(p)
+}
+
+
+type ignoredCancellationsOption float64
+
+
+func (option ignoredCancellationsOption) applyOption(config *config) {
+	config.ignoreCancellationProbability = float64(option)
+}
@@ -0,0 +1,198 @@
+package toiltest
+
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+
+// Timeout is how long the Expect… and Assert… helpers (that do not take a timeout)
+// wait for what they expect to happen.
+var Timeout = 5 * time.Second
+
+
+// Toiler is an interface that wraps the Toil method.
+//
+// (toil.Group and toil.Toiler both are Toilers.)
+type Toiler interface {
+	Toil()
+}
+
+
+// AssertEventually calls `condition` (repeatedly) until it returns true. If it does
+// not return true within the timeout, then the test is failed (with t.Errorf).
+//
+// It returns whether `condition` returned true.
+//
+// Example:
+//
+//	if !toiltest.AssertEventually(t, time.Second, func() bool { return 2 == recorder.NumToiling() }) {
+//		return
+//	}
+func AssertEventually(t testing.TB, timeout time.Duration, condition func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if condition() {
+		return true
+	}
+
+	t.Errorf("Expected the condition to eventually be true (within %v), but it was not.", timeout)
+	return false
+}
+
+
+// AssertNoGoroutineLeaks fails the test (with t.Errorf) if, when the test finishes,
+// there are goroutines that were not there when AssertNoGoroutineLeaks was called.
+// (After waiting up to Timeout for any such goroutines to finish.)
+//
+// NOTE that a toil.Group keeps some goroutines of its own until it is done. I.e., until
+// it has been stopped (see the Stop method of toil.Supervisor), and all its toilers have
+// finished toiling. So, a test should stop the toil.Groups it makes.
+//
+// It should be called at the beginning of a test. For example:
+//
+//	func TestSomething(t *testing.T) {
+//		toiltest.AssertNoGoroutineLeaks(t)
+//
+//		//@TODO
+//	}
+//
+// NOTE that tests that use AssertNoGoroutineLeaks should not be run in parallel with
+// other tests (i.e., should not call t.Parallel), since it looks at all goroutines.
+func AssertNoGoroutineLeaks(t testing.TB) {
+	t.Helper()
+
+	before := goroutines()
+
+	t.Cleanup(func() {
+		t.Helper()
+
+		deadline := time.Now().Add(Timeout)
+
+		leaked := leakedGoroutines(before)
+		for 0 < len(leaked) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			leaked = leakedGoroutines(before)
+		}
+
+		if 0 < len(leaked) {
+			t.Errorf("Expected no goroutines to be leaked, but actually %d were:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	})
+}
+
+
+// goroutines returns the stacks of all the goroutines, by goroutine header. (For
+// example, "goroutine 7".)
+func goroutines() map[string]string {
+	buffer := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buffer, true)
+		if n < len(buffer) {
+			buffer = buffer[:n]
+			break
+		}
+		buffer = make([]byte, 2*len(buffer))
+	}
+
+	stacks := map[string]string{}
+	for _,stack := range strings.Split(string(buffer), "\n\n") {
+		header, _, _ := strings.Cut(stack, " [")
+		stacks[header] = stack
+	}
+
+	return stacks
+}
+
+
+// leakedGoroutines returns the stacks of the goroutines that were not in `before`.
+func leakedGoroutines(before map[string]string) []string {
+	var leaked []string
+
+	for header,stack := range goroutines() {
+		if _, ok := before[header]; ok {
+			continue
+		}
+
+		leaked = append(leaked, stack)
+	}
+
+	sort.Strings(leaked)
+
+	return leaked
+}
+
+
+// ExpectPanic calls group.Toil() and expects it to panic() with `value` (within
+// Timeout). If it does not, then the test is failed (with t.Errorf).
+//
+// This is for a toil.Group with the toil.Propagate PanicPolicy (which is the default),
+// whose Toil method panic()s with the panic value of a toiler that panic()ed.
+//
+// It returns whether group.Toil() panic()ed with `value`.
+func ExpectPanic(t testing.TB, group Toiler, value interface{}) bool {
+	t.Helper()
+
+	type result struct {
+		panicked   bool
+		panicValue interface{}
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		panicked := true
+		defer func() {
+			panicValue := recover()
+			resultCh <- result{panicked:panicked, panicValue:panicValue}
+		}()
+
+		group.Toil()
+		panicked = false
+	}()
+
+	select {
+	case r := <-resultCh:
+		if !r.panicked {
+			t.Errorf("Expected Toil() to panic() with [%v], but it returned instead.", value)
+			return false
+		}
+		if !reflect.DeepEqual(value, r.panicValue) {
+			t.Errorf("Expected Toil() to panic() with [%v], but actually panic()ed with [%v].", value, r.panicValue)
+			return false
+		}
+		return true
+	case <-time.After(Timeout):
+		t.Errorf("Expected Toil() to panic() with [%v], but it did not (within %v).", value, Timeout)
+		return false
+	}
+}
+
+
+// ExpectReturnedNotice expects the ReturnedNotice method of `recorder` to have been
+// called (within Timeout). If it was not, then the test is failed (with t.Errorf).
+//
+// It returns whether the ReturnedNotice method was called.
+func ExpectReturnedNotice(t testing.TB, recorder *ToilRecorder) bool {
+	t.Helper()
+
+	if !recorder.WaitForCalls(CallReturnedNotice, 1, Timeout) {
+		t.Errorf("Expected ReturnedNotice() to have been called, but it was not (within %v).", Timeout)
+		return false
+	}
+
+	return true
+}
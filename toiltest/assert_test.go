@@ -0,0 +1,59 @@
+package toiltest_test
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil"
+	"github.com/reiver/go-toil/toiltest"
+
+	"time"
+)
+
+
+func TestToiltestHelpers(t *testing.T) {
+
+	toiltest.AssertNoGoroutineLeaks(t)
+
+	{
+		toiler := toiltest.NewRecorder()
+		toiler.ToilFunc(func(){
+			go toiler.Terminate()
+		})
+
+		group := toil.NewGroup()
+		group.Register(toiler)
+		group.Toil()
+		group.Stop()
+
+		toiltest.ExpectReturnedNotice(t, toiler)
+	}
+
+	{
+		toiler := toiltest.NewRecorder()
+		toiler.ToilFunc(func(){
+			go toiler.Panic("expected")
+		})
+
+		group := toil.NewGroup()
+		group.Register(toiler)
+
+		toiltest.ExpectPanic(t, group, "expected")
+		group.Stop()
+	}
+
+	{
+		toiler := toiltest.NewRecorder()
+
+		group := toil.NewGroup()
+		group.Register(toiler)
+		go group.Toil()
+
+		toiltest.AssertEventually(t, time.Second, func() bool {
+			return 1 == toiler.NumToiling()
+		})
+
+		toiler.Terminate()
+		group.Stop()
+	}
+}
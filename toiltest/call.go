@@ -0,0 +1,53 @@
+package toiltest
+
+
+import (
+	"fmt"
+	"time"
+)
+
+
+// CallKind is the kind of a Call.
+type CallKind int
+
+const (
+	CallToilStarted     CallKind = iota + 1 // Toil() was called.
+	CallToilReturned                        // Toil() returned (gracefully).
+	CallToilPanicked                        // Toil() panic()ed.
+	CallReturnedNotice                      // ReturnedNotice() was called.
+	CallPanickedNotice                      // PanickedNotice() was called.
+	CallRecoveredNotice                     // RecoveredNotice() was called.
+)
+
+
+// String is part of the fmt.Stringer interface.
+func (kind CallKind) String() string {
+	switch kind {
+	case CallToilStarted:
+		return "Toil started"
+	case CallToilReturned:
+		return "Toil returned"
+	case CallToilPanicked:
+		return "Toil panicked"
+	case CallReturnedNotice:
+		return "ReturnedNotice"
+	case CallPanickedNotice:
+		return "PanickedNotice"
+	case CallRecoveredNotice:
+		return "RecoveredNotice"
+	default:
+		return fmt.Sprintf("CallKind(%d)", int(kind))
+	}
+}
+
+
+// Call is a record of a call on (one of the methods of) a ToilRecorder. (See the
+// History method of ToilRecorder.)
+type Call struct {
+	Kind CallKind
+	Time time.Time
+
+	// Value is the panic value, for CallToilPanicked, CallPanickedNotice and
+	// CallRecoveredNotice calls. Otherwise it is nil.
+	Value interface{}
+}
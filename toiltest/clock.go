@@ -0,0 +1,262 @@
+package toiltest
+
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+
+// FakeClock is a fake clock, whose time only moves when its Advance method is called.
+//
+// It is an implementation of toil.Clock, so it can be passed to a toil.Group with
+// toil.WithClock, to make tests of time-based features (such as timeouts, start rates,
+// staggered starts, heartbeats, the watchdog, etc) deterministic. For example:
+//
+//	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+//
+//	group := toil.NewGroup(toil.WithClock(clock))
+//
+//	// ...
+//
+//	clock.BlockUntilWaiters(1)
+//	clock.Advance(time.Minute)
+//
+// A FakeClock is safe to use from many goroutines at the same time.
+type FakeClock struct {
+	mutex     sync.Mutex
+	now       time.Time
+	waiters   []*fakeWaiter
+	changedCh chan struct{} // NOTE that this is closed (and replaced) whenever a waiter is added.
+}
+
+
+// fakeWaiter is a timer, ticker, or "after func" of a FakeClock.
+type fakeWaiter struct {
+	when   time.Time
+	period time.Duration // NOTE that this is only non-zero for tickers.
+	ch     chan time.Time
+	fn     func()
+}
+
+
+// NewFakeClock returns a FakeClock whose current time is `now`.
+func NewFakeClock(now time.Time) *FakeClock {
+	clock := FakeClock{
+		now:now,
+		changedCh:make(chan struct{}),
+	}
+
+	return &clock
+}
+
+
+// Now returns the (fake) current time.
+func (clock *FakeClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.now
+}
+
+
+// NewTimer returns a channel that the (fake) current time is sent on once the clock
+// has been advanced by `d`, and a func that stops the timer.
+func (clock *FakeClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	waiter := &fakeWaiter{
+		ch:make(chan time.Time, 1),
+	}
+
+	clock.add(waiter, d)
+
+	return waiter.ch, func() bool {
+		return clock.remove(waiter)
+	}
+}
+
+
+// NewTicker returns a channel that the (fake) current time is sent on every time the
+// clock has been advanced by (another) `d`, and a func that stops the ticker.
+//
+// Like a real ticker, ticks are dropped if the channel is not being received from.
+func (clock *FakeClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	if d <= 0 {
+		panic("toiltest: non-positive interval for FakeClock.NewTicker")
+	}
+
+	waiter := &fakeWaiter{
+		period:d,
+		ch:make(chan time.Time, 1),
+	}
+
+	clock.add(waiter, d)
+
+	return waiter.ch, func() {
+		clock.remove(waiter)
+	}
+}
+
+
+// AfterFunc calls `fn` (in its own goroutine) once the clock has been advanced by `d`,
+// and returns a func that stops that from happening.
+func (clock *FakeClock) AfterFunc(d time.Duration, fn func()) func() bool {
+	waiter := &fakeWaiter{
+		fn:fn,
+	}
+
+	clock.add(waiter, d)
+
+	return func() bool {
+		return clock.remove(waiter)
+	}
+}
+
+
+// Advance moves the (fake) current time forward by `d`, firing (in order) all the
+// timers, tickers and "after funcs" that are due along the way.
+func (clock *FakeClock) Advance(d time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	end := clock.now.Add(d)
+
+	for {
+		waiter := clock.next()
+		if nil == waiter || waiter.when.After(end) {
+			break
+		}
+
+		clock.now = waiter.when
+		clock.fire(waiter)
+	}
+
+	clock.now = end
+}
+
+
+// BlockUntilWaiters blocks until there are (at least) `n` timers, tickers and
+// "after funcs" waiting on the clock.
+//
+// This is useful for making sure that the code being tested is waiting on the clock
+// before calling Advance.
+func (clock *FakeClock) BlockUntilWaiters(n int) {
+	for {
+		clock.mutex.Lock()
+		numWaiters := len(clock.waiters)
+		changedCh  := clock.changedCh
+		clock.mutex.Unlock()
+
+		if n <= numWaiters {
+			return
+		}
+
+		<-changedCh
+	}
+}
+
+
+// NumWaiters returns the number of timers, tickers and "after funcs" waiting on
+// the clock.
+func (clock *FakeClock) NumWaiters() int {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return len(clock.waiters)
+}
+
+
+// Deadlines returns when each of the timers, tickers and "after funcs" waiting on
+// the clock will (next) fire, in order.
+func (clock *FakeClock) Deadlines() []time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	deadlines := make([]time.Time, len(clock.waiters))
+	for i,waiter := range clock.waiters {
+		deadlines[i] = waiter.when
+	}
+
+	sort.Slice(deadlines, func(i, j int) bool {
+		return deadlines[i].Before(deadlines[j])
+	})
+
+	return deadlines
+}
+
+
+// add adds a waiter that fires after `d`. (Or immediately, if `d` is not positive.)
+func (clock *FakeClock) add(waiter *fakeWaiter, d time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	waiter.when = clock.now.Add(d)
+
+	if d <= 0 {
+		clock.fire(waiter)
+		return
+	}
+
+	clock.waiters = append(clock.waiters, waiter)
+
+	close(clock.changedCh)
+	clock.changedCh = make(chan struct{})
+}
+
+
+// remove removes a waiter. It returns false if the waiter was not waiting (because
+// it already fired, or was already removed).
+func (clock *FakeClock) remove(waiter *fakeWaiter) bool {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.removeLocked(waiter)
+}
+
+
+func (clock *FakeClock) removeLocked(waiter *fakeWaiter) bool {
+	for i,w := range clock.waiters {
+		if w == waiter {
+			clock.waiters = append(clock.waiters[:i], clock.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+
+// next returns the waiter that will fire next. (Or nil.)
+func (clock *FakeClock) next() *fakeWaiter {
+	var next *fakeWaiter
+	for _,waiter := range clock.waiters {
+		if nil == next || waiter.when.Before(next.when) {
+			next = waiter
+		}
+	}
+
+	return next
+}
+
+
+// fire fires a waiter (that is due).
+//
+// NOTE that the mutex must be locked when this is called.
+func (clock *FakeClock) fire(waiter *fakeWaiter) {
+	if 0 < waiter.period {
+		waiter.when = waiter.when.Add(waiter.period)
+	} else {
+		clock.removeLocked(waiter)
+	}
+
+	if nil != waiter.fn {
+		go waiter.fn()
+	}
+
+	if nil != waiter.ch {
+		select {
+		case waiter.ch <- clock.now:
+		default:
+		}
+	}
+}
@@ -1,15 +1,30 @@
 package toiltest
 
 
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+
 // ToilRecorder is an implementation of toil.Toiler, as well as has PanickedNotice,
 // ReturnedNotice and RecoveredNotice methods as well. It counts the number of times
 // its Toil() method has been called and has not returned (i.e., is blocking) as
 // well as allows custom code to run when its PanickedNotice, ReturnedNotice(),
 // RecoveredNotice(), or Toil() methods are called.
+//
+// It also records the history of the calls on its methods. (See History.)
+//
+// A ToilRecorder is safe to use from many goroutines at the same time.
 type ToilRecorder struct {
 	panicCh     chan struct{value interface{}}
 	terminateCh chan struct{doneCh chan struct{}}
+
+	mutex      sync.Mutex
 	numToiling int
+	history    []Call
+	changedCh  chan struct{} // NOTE that this is closed (and replaced) whenever anything is recorded.
 
 	toilFunc       func()
 
@@ -28,6 +43,7 @@ func NewRecorder() *ToilRecorder {
 	toilRecorder := ToilRecorder{
 		panicCh:panicCh,
 		terminateCh:terminateCh,
+		changedCh:make(chan struct{}),
 	}
 
 	return &toilRecorder
@@ -37,6 +53,9 @@ func NewRecorder() *ToilRecorder {
 // ToilFunc registers the "toil function" that will be called as part of when the
 // ToilRecorder's Toil() method is called.
 func (toiler *ToilRecorder) ToilFunc(fn func()) {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
 	toiler.toilFunc = fn
 }
 
@@ -46,18 +65,27 @@ func (toiler *ToilRecorder) ToilFunc(fn func()) {
 // ReturnedNoticeFunc registers the func that will be called as part of when the
 // ReturnedNotice() method is called.
 func (toiler *ToilRecorder) ReturnedNoticeFunc(fn func()) {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
 	toiler.returnedNoticeFunc = fn
 }
 
 // PanickedNoticeFunc registers the func that will be called as part of when the
 // PanickedNotice() method is called.
 func (toiler *ToilRecorder) PanickedNoticeFunc(fn func(interface{})) {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
 	toiler.panickedNoticeFunc = fn
 }
 
 // RecoveredNoticeFunc registers the func that will be called as part of when the
 // RecoveredNotice() method is called.
 func (toiler *ToilRecorder) RecoveredNoticeFunc(fn func(interface{})) {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
 	toiler.recoveredNoticeFunc = fn
 }
 
@@ -65,25 +93,208 @@ func (toiler *ToilRecorder) RecoveredNoticeFunc(fn func(interface{})) {
 
 // NumToiling returns the number of active calls to its Toil() method.
 func (toiler *ToilRecorder) NumToiling() int {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
 	return toiler.numToiling
 }
 
 
+// History returns (a copy of) the history of the calls on its methods, in the order
+// they happened.
+func (toiler *ToilRecorder) History() []Call {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	return append([]Call(nil), toiler.history...)
+}
+
+
+// NumCalls returns the number of calls of the kind `kind` in its history.
+func (toiler *ToilRecorder) NumCalls(kind CallKind) int {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	n := 0
+	for _,call := range toiler.history {
+		if kind == call.Kind {
+			n++
+		}
+	}
+
+	return n
+}
+
+
+// WaitForToiling waits until there are (at least) `n` active calls to its Toil()
+// method. It returns false if that did not happen within the timeout.
+func (toiler *ToilRecorder) WaitForToiling(n int, timeout time.Duration) bool {
+	return toiler.waitFor(timeout, func() bool {
+		return n <= toiler.numToiling
+	})
+}
+
+
+// WaitForCalls waits until there are (at least) `n` calls of the kind `kind` in its
+// history. It returns false if that did not happen within the timeout.
+func (toiler *ToilRecorder) WaitForCalls(kind CallKind, n int, timeout time.Duration) bool {
+	return toiler.waitFor(timeout, func() bool {
+		num := 0
+		for _,call := range toiler.history {
+			if kind == call.Kind {
+				num++
+			}
+		}
+
+		return n <= num
+	})
+}
+
+
+// waitFor waits until `fn` returns true (which is called with the mutex locked),
+// or the timeout. (A negative timeout means no timeout.)
+func (toiler *ToilRecorder) waitFor(timeout time.Duration, fn func() bool) bool {
+	var timerCh <-chan time.Time
+	if 0 <= timeout {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timerCh = timer.C
+	}
+
+	for {
+		toiler.mutex.Lock()
+		done      := fn()
+		changedCh := toiler.changedCh
+		toiler.mutex.Unlock()
+
+		if done {
+			return true
+		}
+
+		select {
+		case <-changedCh:
+		case <-timerCh:
+			return false
+		}
+	}
+}
+
+
+// record adds a call to the history, and adds `delta` to the number of active calls
+// to its Toil() method.
+func (toiler *ToilRecorder) record(kind CallKind, value interface{}, delta int) {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	toiler.numToiling += delta
+
+	toiler.history = append(toiler.history, Call{
+		Kind:kind,
+		Time:time.Now(),
+		Value:value,
+	})
+
+	close(toiler.changedCh)
+	toiler.changedCh = make(chan struct{})
+}
+
+
 // Panic causes one of the still active (i.e., blocking) calls to Toil()
 // on itself to panic().
 //
 // If there are not active (i.e., blocking) calls to Toil() on itself,
 // then it will block until there is one.
 //
-// One use for this method is to check if its RecoveredNotice() method was
-// called by the toil.Group it is in (due to the panic()).
+// One use for this method is to check if its PanickedNotice() method was
+// called by the toil.Group it is in (due to the panic()). And, if the toil.Group
+// has a PanicPolicy that recovers from panics (such as toil.Isolate), to check
+// if its RecoveredNotice() method was called by the toil.Group.
 func (toiler *ToilRecorder) Panic(value interface{}) {
 
 	toiler.panicCh <- struct{value interface{}}{
 		value:value,
 	}
 
-//@TODO: Is there a way to wait for this to complete?
+	// NOTE that this returns before the toil.Group has recovered the panic() (and
+	// called the notice methods). Use PanicAndWait to wait for that.
+}
+
+
+// PanicAndWait is like Panic, except that it also blocks until the toil.Group it is
+// in has observed the panic(). I.e., until its PanickedNotice() method has been called
+// (with `value`).
+//
+// It returns false if that did not happen within Timeout. (For example, because it
+// is not registered with a toil.Group, or the toil.Group was stopped.)
+//
+// If the toil.Group has a PanicPolicy that recovers from panics (such as toil.Isolate),
+// then use PanicAndWaitRecovered to also wait for its RecoveredNotice() method to
+// have been called.
+func (toiler *ToilRecorder) PanicAndWait(value interface{}) bool {
+	return toiler.panicAndWait(value, CallPanickedNotice)
+}
+
+
+// PanicAndWaitRecovered is like PanicAndWait, except that it also blocks until its
+// RecoveredNotice() method has been called (with `value`).
+//
+// It returns false if that did not happen within Timeout.
+func (toiler *ToilRecorder) PanicAndWaitRecovered(value interface{}) bool {
+	return toiler.panicAndWait(value, CallPanickedNotice, CallRecoveredNotice)
+}
+
+
+func (toiler *ToilRecorder) panicAndWait(value interface{}, kinds ...CallKind) bool {
+	deadline := time.Now().Add(Timeout)
+
+	toiler.mutex.Lock()
+	before := make([]int, len(kinds))
+	for i,kind := range kinds {
+		before[i] = toiler.numCallsWithValue(kind, value)
+	}
+	toiler.mutex.Unlock()
+
+	// NOTE that this is like calling the Panic method, except that it does not block
+	// forever if there is no active call to Toil().
+	timer := time.NewTimer(Timeout)
+	defer timer.Stop()
+
+	select {
+	case toiler.panicCh <- struct{value interface{}}{value:value}:
+	case <-timer.C:
+		return false
+	}
+
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return toiler.waitFor(remaining, func() bool {
+		for i,kind := range kinds {
+			if toiler.numCallsWithValue(kind, value) <= before[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+
+// numCallsWithValue returns the number of calls of the kind `kind` with the value
+// `value` in its history.
+//
+// NOTE that the mutex must be locked when this is called.
+func (toiler *ToilRecorder) numCallsWithValue(kind CallKind, value interface{}) int {
+	n := 0
+	for _,call := range toiler.history {
+		if kind == call.Kind && reflect.DeepEqual(value, call.Value) {
+			n++
+		}
+	}
+
+	return n
 }
 
 
@@ -93,8 +304,8 @@ func (toiler *ToilRecorder) Panic(value interface{}) {
 // If there are not active (i.e., blocking) calls to Toil() on itself,
 // then it will block until there is one.
 //
-// One use for this method is to check if its Terminated() method was
-// call by the toil.Group it is in (due to the gracefull return).
+// One use for this method is to check if its ReturnedNotice() method was
+// called by the toil.Group it is in (due to the graceful return).
 func (toiler *ToilRecorder) Terminate() {
 	doneCh := make(chan struct{})
 
@@ -108,22 +319,27 @@ func (toiler *ToilRecorder) Terminate() {
 
 // Toil is part of the toil.Toiler interface.
 func (toiler *ToilRecorder) Toil() {
-	toiler.numToiling++
+	toiler.record(CallToilStarted, nil, +1)
+
+	toiler.mutex.Lock()
+	toilFunc := toiler.toilFunc
+	toiler.mutex.Unlock()
 
-	if nil != toiler.toilFunc {
-		toiler.toilFunc()
+	if nil != toilFunc {
+		toilFunc()
 	}
 
 	var doneCh chan struct{}
 
 	select {
 	case panicRequest := <-toiler.panicCh:
+		toiler.record(CallToilPanicked, panicRequest.value, -1)
 		panic(panicRequest.value)
 	case terminateRequest := <-toiler.terminateCh:
 		doneCh = terminateRequest.doneCh
 	}
 
-	toiler.numToiling--
+	toiler.record(CallToilReturned, nil, -1)
 
 	if nil != doneCh {
 		doneCh <- struct{}{}
@@ -135,23 +351,47 @@ func (toiler *ToilRecorder) Toil() {
 // ReturnedNotice will call the func registerd with the call to the
 // ReturnedNoticeFunc method.
 func (toiler *ToilRecorder) ReturnedNotice() {
-	if nil != toiler.returnedNoticeFunc {
-		toiler.returnedNoticeFunc()
+	toiler.mutex.Lock()
+	returnedNoticeFunc := toiler.returnedNoticeFunc
+	toiler.mutex.Unlock()
+
+	if nil != returnedNoticeFunc {
+		returnedNoticeFunc()
 	}
+
+	// NOTE that this is recorded after the registered func is called, so that
+	// anything waiting on this call knows the registered func is done.
+	toiler.record(CallReturnedNotice, nil, 0)
 }
 
 // PanickedNotice will call the func registerd with the call to the
 // PanickedNoticeFunc method.
 func (toiler *ToilRecorder) PanickedNotice(panicValue interface{}) {
-	if nil != toiler.panickedNoticeFunc {
-		toiler.panickedNoticeFunc(panicValue)
+	toiler.mutex.Lock()
+	panickedNoticeFunc := toiler.panickedNoticeFunc
+	toiler.mutex.Unlock()
+
+	if nil != panickedNoticeFunc {
+		panickedNoticeFunc(panicValue)
 	}
+
+	// NOTE that this is recorded after the registered func is called, so that
+	// anything waiting on this call knows the registered func is done.
+	toiler.record(CallPanickedNotice, panicValue, 0)
 }
 
 // RecoveredNotice will call the func registerd with the call to the
 // RecoveredNoticeFunc method.
 func (toiler *ToilRecorder) RecoveredNotice(panicValue interface{}) {
-	if nil != toiler.recoveredNoticeFunc {
-		toiler.recoveredNoticeFunc(panicValue)
+	toiler.mutex.Lock()
+	recoveredNoticeFunc := toiler.recoveredNoticeFunc
+	toiler.mutex.Unlock()
+
+	if nil != recoveredNoticeFunc {
+		recoveredNoticeFunc(panicValue)
 	}
+
+	// NOTE that this is recorded after the registered func is called, so that
+	// anything waiting on this call knows the registered func is done.
+	toiler.record(CallRecoveredNotice, panicValue, 0)
 }
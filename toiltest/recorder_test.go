@@ -0,0 +1,117 @@
+package toiltest_test
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil"
+	"github.com/reiver/go-toil/toiltest"
+
+	"time"
+)
+
+
+func TestToilRecorderHistory(t *testing.T) {
+
+	toiler := toiltest.NewRecorder()
+
+	// NOTE that synchronous notices are used so that the order of the notices is determined.
+	group := toil.NewGroup(toil.Isolate, toil.SynchronousNotices(0))
+	group.Register(toiler)
+
+	go group.Toil()
+
+	if !toiler.WaitForToiling(1, time.Second) {
+		t.Fatalf("Expected the toiler to be toiling, but it was not.")
+	}
+
+	toiler.Panic("history")
+
+	if !toiler.WaitForCalls(toiltest.CallRecoveredNotice, 1, time.Second) {
+		t.Fatalf("Expected RecoveredNotice() to have been called, but it was not. History: %v", toiler.History())
+	}
+
+	expected := []toiltest.CallKind{
+		toiltest.CallToilStarted,
+		toiltest.CallToilPanicked,
+		toiltest.CallPanickedNotice,
+		toiltest.CallRecoveredNotice,
+	}
+
+	history := toiler.History()
+
+	if expected, actual := len(expected), len(history); expected != actual {
+		t.Fatalf("Expected the length of the history to be %d, but actually was %d. History: %v", expected, actual, history)
+	}
+
+	for i, call := range history {
+		if expected, actual := expected[i], call.Kind; expected != actual {
+			t.Errorf("For call #%d, expected the kind to be %v, but actually was %v.", i, expected, actual)
+		}
+		if 0 < i && call.Time.Before(history[i-1].Time) {
+			t.Errorf("For call #%d, expected the time to not be before the previous call's time, but it was.", i)
+		}
+	}
+
+	if expected, actual := "history", history[1].Value; expected != actual {
+		t.Errorf("Expected the panic value to be %q, but actually was %v.", expected, actual)
+	}
+
+	if expected, actual := 0, toiler.NumToiling(); expected != actual {
+		t.Errorf("Expected the number of toiling toilers to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestToilRecorderPanicAndWait(t *testing.T) {
+
+	var panickedNoticeDone bool
+
+	toiler := toiltest.NewRecorder()
+	toiler.PanickedNoticeFunc(func(interface{}){
+		time.Sleep(10 * time.Millisecond)
+		panickedNoticeDone = true
+	})
+
+	group := toil.NewGroup(toil.Isolate)
+	group.Register(toiler)
+	group.Register(toiltest.NewRecorder()) // Keeps the group toiling.
+
+	go group.Toil()
+
+	if !toiler.PanicAndWaitRecovered("waited") {
+		t.Fatalf("Expected PanicAndWaitRecovered() to return true, but it did not.")
+	}
+
+	if !panickedNoticeDone {
+		t.Errorf("Expected the PanickedNotice() func to be done, but it was not.")
+	}
+
+	if expected, actual := 1, toiler.NumCalls(toiltest.CallRecoveredNotice); expected != actual {
+		t.Errorf("Expected the number of RecoveredNotice() calls to be %d, but actually was %d.", expected, actual)
+	}
+
+	if expected, actual := 1, len(group.Panics()); expected != actual {
+		t.Errorf("Expected the number of recorded panics to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestToilRecorderPanicAndWaitNotRegistered(t *testing.T) {
+
+	timeout := toiltest.Timeout
+	toiltest.Timeout = 20 * time.Millisecond
+	defer func() {
+		toiltest.Timeout = timeout
+	}()
+
+	// NOTE that this recorder is not registered with a Group, so it never toils.
+	toiler := toiltest.NewRecorder()
+
+	if toiler.PanicAndWait("never") {
+		t.Errorf("Expected PanicAndWait() to return false, but it returned true.")
+	}
+	if toiler.PanicAndWaitRecovered("never") {
+		t.Errorf("Expected PanicAndWaitRecovered() to return false, but it returned true.")
+	}
+}
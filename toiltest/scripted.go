@@ -0,0 +1,334 @@
+package toiltest
+
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+
+// Step is a step of a Script. (See Block, Sleep, Panic, Return, ReturnError and
+// IgnoreCancellation.)
+type Step struct {
+	kind     stepKind
+	duration time.Duration
+	value    interface{}
+	err      error
+}
+
+
+type stepKind int
+
+const (
+	blockStepKind stepKind = iota
+	sleepStepKind
+	panicStepKind
+	returnStepKind
+	ignoreCancellationStepKind
+)
+
+
+// Block returns a Step that blocks until the ScriptedToiler is signalled (see the
+// Signal method of ScriptedToiler), or the run is cancelled.
+func Block() Step {
+	return Step{kind:blockStepKind}
+}
+
+
+// Sleep returns a Step that sleeps for `d`, or until the run is cancelled.
+//
+// It uses the ScriptedToiler's Clock. (See NewScriptedToilerWithClock.)
+func Sleep(d time.Duration) Step {
+	return Step{kind:sleepStepKind, duration:d}
+}
+
+
+// Panic returns a Step that panic()s with `value`.
+func Panic(value interface{}) Step {
+	return Step{kind:panicStepKind, value:value}
+}
+
+
+// Return returns a Step that makes the run return (gracefully), with `value` as
+// its result. (See the ToilResult method of ScriptedToiler.)
+func Return(value interface{}) Step {
+	return Step{kind:returnStepKind, value:value}
+}
+
+
+// ReturnError returns a Step that makes the run return, with `err` as its error.
+// (See the ToilResult method of ScriptedToiler.)
+func ReturnError(err error) Step {
+	return Step{kind:returnStepKind, err:err}
+}
+
+
+// IgnoreCancellation returns a Step that makes the rest of the run ignore being
+// cancelled (by its context, or by the Stop method of ScriptedToiler). I.e., so that
+// the Block and Sleep steps after it do not end early.
+func IgnoreCancellation() Step {
+	return Step{kind:ignoreCancellationStepKind}
+}
+
+
+// Script is what a ScriptedToiler does during a run, step by step.
+//
+// A run that gets to the end of its Script returns (gracefully).
+type Script []Step
+
+
+// Clock is an interface that wraps the NewTimer method. It is what a ScriptedToiler
+// uses for its Sleep steps.
+//
+// (toil.Clock and *FakeClock both are Clocks.)
+type Clock interface {
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+
+// realClock is the Clock that uses the time package.
+type realClock struct{}
+
+
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+
+	return timer.C, timer.Stop
+}
+
+
+// ScriptedToiler is an implementation of toil.Toiler (as well as toil.ContextToiler
+// and toil.Stopper) that follows a Script for each of its runs (i.e., each call to its
+// Toil, ToilContext or ToilResult methods).
+//
+// It is for testing supervisor, restart and shutdown behaviors as tables. For example:
+//
+//	toiler := toiltest.NewScriptedToiler(
+//		toiltest.Script{toiltest.Sleep(10*time.Millisecond), toiltest.Panic("boom")},
+//		toiltest.Script{toiltest.Block()},
+//	)
+//
+// The first run follows the first Script, the second run the second Script, etc.
+// Runs after the last Script follow the last Script.
+//
+// A run is cancelled when its context is done, or when the Stop method is called.
+//
+// A ScriptedToiler is safe to use from many goroutines at the same time.
+type ScriptedToiler struct {
+	scripts  []Script
+	clock    Clock
+	signalCh chan struct{}
+
+	mutex      sync.Mutex
+	numRuns    int
+	numToiling int
+	stoppedCh  chan struct{} // NOTE that this is closed (and replaced) whenever the Stop method is called.
+	changedCh  chan struct{} // NOTE that this is closed (and replaced) whenever a run starts or ends.
+}
+
+
+// NewScriptedToiler returns a ScriptedToiler that follows `scripts`.
+//
+// Its Sleep steps use the real clock. (See NewScriptedToilerWithClock.)
+func NewScriptedToiler(scripts ...Script) *ScriptedToiler {
+	return NewScriptedToilerWithClock(realClock{}, scripts...)
+}
+
+
+// NewScriptedToilerWithClock returns a ScriptedToiler that follows `scripts`, and whose
+// Sleep steps use `clock`. For example, with a FakeClock:
+//
+//	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+//
+//	toiler := toiltest.NewScriptedToilerWithClock(clock,
+//		toiltest.Script{toiltest.Sleep(time.Minute), toiltest.Panic("boom")},
+//	)
+//
+//	// ...
+//
+//	clock.BlockUntilWaiters(1)
+//	clock.Advance(time.Minute)
+func NewScriptedToilerWithClock(clock Clock, scripts ...Script) *ScriptedToiler {
+	if nil == clock {
+		clock = realClock{}
+	}
+
+	toiler := ScriptedToiler{
+		scripts:scripts,
+		clock:clock,
+		signalCh:make(chan struct{}),
+		stoppedCh:make(chan struct{}),
+		changedCh:make(chan struct{}),
+	}
+
+	return &toiler
+}
+
+
+// Toil is part of the toil.Toiler interface.
+func (toiler *ScriptedToiler) Toil() {
+	toiler.ToilResult(context.Background())
+}
+
+
+// ToilContext is part of the toil.ContextToiler interface.
+func (toiler *ScriptedToiler) ToilContext(ctx context.Context) {
+	toiler.ToilResult(ctx)
+}
+
+
+// ToilResult does a run, and returns the value (or the error) the run's Script returned
+// with. (See Return and ReturnError.)
+//
+// It can be used as a toil.ResultToilerFunc. For example:
+//
+//	resultGroup.Register(toil.ResultToilerFunc[interface{}](toiler.ToilResult))
+func (toiler *ScriptedToiler) ToilResult(ctx context.Context) (interface{}, error) {
+	script, stoppedCh := toiler.begin()
+	defer toiler.end()
+
+	ignoreCancellation := false
+
+	for _,step := range script {
+		doneCh, stopCh := ctx.Done(), stoppedCh
+		if ignoreCancellation {
+			doneCh, stopCh = nil, nil
+		}
+
+		switch step.kind {
+		case blockStepKind:
+			select {
+			case <-toiler.signalCh:
+			case <-doneCh:
+				return nil, ctx.Err()
+			case <-stopCh:
+				return nil, context.Canceled
+			}
+		case sleepStepKind:
+			timerCh, stopTimer := toiler.clock.NewTimer(step.duration)
+			select {
+			case <-timerCh:
+			case <-doneCh:
+				stopTimer()
+				return nil, ctx.Err()
+			case <-stopCh:
+				stopTimer()
+				return nil, context.Canceled
+			}
+		case panicStepKind:
+			panic(step.value)
+		case returnStepKind:
+			return step.value, step.err
+		case ignoreCancellationStepKind:
+			ignoreCancellation = true
+		}
+	}
+
+	return nil, nil
+}
+
+
+// Stop is part of the toil.Stopper interface. It cancels all the current runs.
+func (toiler *ScriptedToiler) Stop() {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	// NOTE that the channel is replaced, so that the runs that start after this are
+	// not cancelled.
+	close(toiler.stoppedCh)
+	toiler.stoppedCh = make(chan struct{})
+}
+
+
+// Signal unblocks one run that is at a Block step.
+//
+// If there is no run at a Block step, then it will block until there is one.
+func (toiler *ScriptedToiler) Signal() {
+	toiler.signalCh <- struct{}{}
+}
+
+
+// NumRuns returns the number of runs that have started.
+func (toiler *ScriptedToiler) NumRuns() int {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	return toiler.numRuns
+}
+
+
+// NumToiling returns the number of runs that have started, but not yet finished.
+func (toiler *ScriptedToiler) NumToiling() int {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	return toiler.numToiling
+}
+
+
+// WaitForRuns waits until (at least) `n` runs have started. It returns false if that
+// did not happen within the timeout.
+func (toiler *ScriptedToiler) WaitForRuns(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		toiler.mutex.Lock()
+		numRuns   := toiler.numRuns
+		changedCh := toiler.changedCh
+		toiler.mutex.Unlock()
+
+		if n <= numRuns {
+			return true
+		}
+
+		select {
+		case <-changedCh:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+
+// begin starts a run, and returns its Script, and the channel that is closed when the
+// run is stopped (by the Stop method).
+func (toiler *ScriptedToiler) begin() (Script, <-chan struct{}) {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	toiler.numRuns++
+	toiler.numToiling++
+	toiler.changed()
+
+	if 0 == len(toiler.scripts) {
+		return nil, toiler.stoppedCh
+	}
+
+	i := toiler.numRuns - 1
+	if len(toiler.scripts) <= i {
+		i = len(toiler.scripts) - 1
+	}
+
+	return toiler.scripts[i], toiler.stoppedCh
+}
+
+
+// end finishes a run.
+func (toiler *ScriptedToiler) end() {
+	toiler.mutex.Lock()
+	defer toiler.mutex.Unlock()
+
+	toiler.numToiling--
+	toiler.changed()
+}
+
+
+// changed wakes up anything waiting for a run to start or end.
+//
+// NOTE that the mutex must be locked when this is called.
+func (toiler *ScriptedToiler) changed() {
+	close(toiler.changedCh)
+	toiler.changedCh = make(chan struct{})
+}
@@ -0,0 +1,281 @@
+package toiltest_test
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil"
+	"github.com/reiver/go-toil/toiltest"
+
+	"context"
+	"errors"
+	"time"
+)
+
+
+func TestScriptedToiler(t *testing.T) {
+
+	tests := []struct{
+		Options        []toil.GroupOption
+		Scripts        []toiltest.Script
+		Stop           bool
+		ExpectedRuns   int
+		ExpectedPanics int
+	}{
+		{
+			Options: []toil.GroupOption{toil.Isolate},
+			Scripts: []toiltest.Script{
+				{toiltest.Sleep(time.Millisecond), toiltest.Panic("apple")},
+			},
+			ExpectedRuns:   1,
+			ExpectedPanics: 1,
+		},
+		{
+			Options: []toil.GroupOption{toil.Isolate, toil.RestartOnPanic},
+			Scripts: []toiltest.Script{
+				{toiltest.Panic("banana")},
+				{toiltest.Panic("cherry")},
+				{toiltest.Return(nil)},
+			},
+			ExpectedRuns:   3,
+			ExpectedPanics: 0,
+		},
+		{
+			Options: []toil.GroupOption{toil.RestartAlways},
+			Scripts: []toiltest.Script{
+				{toiltest.Return(nil)},
+				{toiltest.Return(nil)},
+				{toiltest.Block()},
+			},
+			Stop:         true,
+			ExpectedRuns: 3,
+		},
+		{
+			Options: []toil.GroupOption{},
+			Scripts: []toiltest.Script{
+				{toiltest.IgnoreCancellation(), toiltest.Sleep(20*time.Millisecond)},
+			},
+			Stop:         true,
+			ExpectedRuns: 1,
+		},
+	}
+
+	for testNumber, test := range tests {
+
+		toiler := toiltest.NewScriptedToiler(test.Scripts...)
+
+		group := toil.NewGroup(test.Options...)
+		group.Register(toiler)
+
+		if test.Stop {
+			go func() {
+				if toiler.WaitForRuns(test.ExpectedRuns, 5*time.Second) {
+					group.Stop()
+				}
+			}()
+		}
+
+		returned, panicValue := toilWithin(group, 5*time.Second)
+		if !returned {
+			t.Errorf("For test #%d, expected Toil() to return, but it did not. (Panic value: %v)", testNumber, panicValue)
+			continue
+		}
+
+		if expected, actual := test.ExpectedRuns, toiler.NumRuns(); expected != actual {
+			t.Errorf("For test #%d, expected the number of runs to be %d, but actually was %d.", testNumber, expected, actual)
+		}
+
+		if expected, actual := test.ExpectedPanics, len(group.Panics()); expected != actual {
+			t.Errorf("For test #%d, expected the number of recorded panics to be %d, but actually was %d.", testNumber, expected, actual)
+		}
+
+		if expected, actual := 0, toiler.NumToiling(); expected != actual {
+			t.Errorf("For test #%d, expected the number of toiling runs to be %d, but actually was %d.", testNumber, expected, actual)
+		}
+	}
+}
+
+
+func TestScriptedToilerSignal(t *testing.T) {
+
+	toiler := toiltest.NewScriptedToiler(
+		toiltest.Script{toiltest.Block(), toiltest.Block()},
+	)
+
+	group := toil.NewGroup()
+	group.Register(toiler)
+
+	doneCh := make(chan struct{})
+	go func() {
+		group.Toil()
+		close(doneCh)
+	}()
+
+	toiler.Signal()
+
+	select {
+	case <-doneCh:
+		t.Fatalf("Expected Toil() to not return after only one signal, but it did.")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	toiler.Signal()
+
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected Toil() to return after two signals, but it did not.")
+	}
+}
+
+
+func TestScriptedToilerResult(t *testing.T) {
+
+	errBad := errors.New("bad")
+
+	toiler := toiltest.NewScriptedToiler(
+		toiltest.Script{toiltest.Return("apple")},
+		toiltest.Script{toiltest.ReturnError(errBad)},
+	)
+
+	resultGroup := toil.NewResultGroup[interface{}]()
+	resultGroup.Register(toil.ResultToilerFunc[interface{}](toiler.ToilResult))
+	resultGroup.Register(toil.ResultToilerFunc[interface{}](toiler.ToilResult))
+
+	results := resultGroup.Wait(context.Background())
+
+	var values []interface{}
+	var errs   []error
+	for _,result := range results {
+		values = append(values, result.Value)
+		errs   = append(errs, result.Err)
+	}
+
+	// NOTE that which of the two toilers does the first run is not determined.
+	if !(("apple" == values[0] && errBad == errs[1]) || ("apple" == values[1] && errBad == errs[0])) {
+		t.Errorf("Expected one result to be \"apple\" and the other to be the error %v, but actually were: %#v", errBad, results)
+	}
+}
+
+
+func TestScriptedToilerSleepClock(t *testing.T) {
+
+	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	toiler := toiltest.NewScriptedToilerWithClock(clock,
+		toiltest.Script{toiltest.Sleep(time.Hour), toiltest.Return("apple")},
+	)
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		value, err := toiler.ToilResult(context.Background())
+		resultCh <- result{value:value, err:err}
+	}()
+
+	clock.BlockUntilWaiters(1)
+
+	// NOTE that the Sleep step waits on the fake clock, so it does not end until the fake
+	// clock is advanced. (No matter how much real time passes.)
+	clock.Advance(time.Hour - time.Nanosecond)
+
+	select {
+	case r := <-resultCh:
+		t.Fatalf("Expected the run to still be sleeping, but it returned: %#v", r)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.Advance(time.Nanosecond)
+
+	select {
+	case r := <-resultCh:
+		if nil != r.err {
+			t.Errorf("Did not expect an error, but actually got one: (%T) %v", r.err, r.err)
+		}
+		if expected, actual := "apple", r.value; expected != actual {
+			t.Errorf("Expected the value to be %q, but actually was %v.", expected, actual)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the run to return after the fake clock was advanced, but it did not.")
+	}
+}
+
+
+func TestScriptedToilerStopIsPerRun(t *testing.T) {
+
+	toiler := toiltest.NewScriptedToiler(
+		toiltest.Script{toiltest.Block()},
+	)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := toiler.ToilResult(context.Background())
+		errCh <- err
+	}()
+
+	if !toiler.WaitForRuns(1, 5*time.Second) {
+		t.Fatalf("Expected the first run to start, but it did not.")
+	}
+
+	toiler.Stop()
+
+	select {
+	case err := <-errCh:
+		if expected, actual := context.Canceled, err; expected != actual {
+			t.Errorf("Expected the first run to return error %v, but actually was %v.", expected, actual)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the first run to return after Stop(), but it did not.")
+	}
+
+	// The Stop before it started does not cancel the second run, so it is still
+	// blocked until it is signalled.
+	go func() {
+		_, err := toiler.ToilResult(context.Background())
+		errCh <- err
+	}()
+
+	toiler.Signal()
+
+	select {
+	case err := <-errCh:
+		if nil != err {
+			t.Errorf("Expected the second run to return without an error, but actually got one: (%T) %v", err, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the second run to return after being signalled, but it did not.")
+	}
+}
+
+
+// toilWithin calls group.Toil(), and returns whether it returned within `timeout`, and
+// the value it panic()ed with (if it panic()ed).
+func toilWithin(group toil.Group, timeout time.Duration) (returned bool, panicValue interface{}) {
+	type result struct {
+		returned   bool
+		panicValue interface{}
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		defer func() {
+			if panicValue := recover(); nil != panicValue {
+				resultCh <- result{panicValue:panicValue}
+			}
+		}()
+
+		group.Toil()
+		resultCh <- result{returned:true}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.returned, r.panicValue
+	case <-time.After(timeout):
+		return false, nil
+	}
+}
@@ -0,0 +1,329 @@
+package toiltest
+
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+
+// Grouper is an interface that wraps the Len, Toil and Stop methods.
+//
+// (The toil.Supervisor that toil.NewGroup returns is a Grouper.)
+type Grouper interface {
+	Len() int
+	Toil()
+	Stop()
+}
+
+
+// Stress is a (property-based) stress test harness for a toil.Group.
+//
+// Its Run method randomly interleaves registering toilers, and calling the Len, Toil
+// and Stop methods of a toil.Group, and making the registered toilers panic() and
+// return, from many goroutines at the same time, and checks that:
+//
+// • the number of registered toilers (as returned by Len) never goes down, and is
+// consistent with the number of toilers registered,
+//
+// • the number of active calls to the Toil method of a registered toiler is never
+// negative, and the Toil method of the toil.Group never panic()s (such as with a
+// negative sync.WaitGroup counter), and
+//
+// • nothing deadlocks. I.e., every operation finishes (and, once all the registered
+// toilers have returned, the Toil method returns) within the timeout.
+//
+// For example:
+//
+//	toiltest.Stress{
+//		Seed: 42,
+//		NewGroup: func() (toiltest.Grouper, func(*toiltest.ToilRecorder)) {
+//			group := toil.NewGroup(toil.Isolate)
+//
+//			return group, func(recorder *toiltest.ToilRecorder) {
+//				group.Register(recorder)
+//			}
+//		},
+//	}.Run(t)
+//
+// The toil.Group that NewGroup returns should have a PanicPolicy that recovers from
+// panics (such as toil.Isolate), and should not restart toilers that return (such as
+// with toil.RestartAlways).
+type Stress struct {
+	// Seed seeds the (pseudo) random choices of operations. Each goroutine gets its
+	// own (pseudo) random source, derived from the seed, so the operations each
+	// goroutine does are reproducible. (How they interleave is not.)
+	Seed int64
+
+	// Goroutines is the number of goroutines doing operations at the same time.
+	// (The default is 8.)
+	Goroutines int
+
+	// Operations is the number of operations each goroutine does. (The default is 100.)
+	Operations int
+
+	// Timeout is how long to wait for all the operations to finish, and then for the
+	// Toil method to return, before deciding there is a deadlock. (The default is
+	// the package's Timeout.)
+	Timeout time.Duration
+
+	// NewGroup returns the toil.Group to stress, and a func that registers a toiler
+	// with it.
+	NewGroup func() (group Grouper, register func(*ToilRecorder))
+}
+
+
+// stressPanic is the panic value of the panic()s the stress test harness makes the
+// registered toilers do.
+type stressPanic struct {
+	goroutine int
+	operation int
+}
+
+
+func (value stressPanic) String() string {
+	return fmt.Sprintf("toiltest: stress panic (goroutine %d, operation %d)", value.goroutine, value.operation)
+}
+
+
+// stressState is the state (shared by all the goroutines) of a run of the stress test harness.
+type stressState struct {
+	t        testing.TB
+	group    Grouper
+	register func(*ToilRecorder)
+
+	toilOnce sync.Once
+	toilCh   chan interface{} // NOTE that what the Toil method panic()ed with (or nil) is sent on this.
+
+	mutex          sync.Mutex
+	recorders      []*ToilRecorder
+	numRegistered  int // The number of registrations that have finished.
+	numRegistering int // The number of registrations that have started.
+	maxLen         int // The largest Len seen so far.
+}
+
+
+// Run runs the stress test.
+func (stress Stress) Run(t testing.TB) {
+	t.Helper()
+
+	if nil == stress.NewGroup {
+		t.Fatalf("toiltest: Stress needs a NewGroup func.")
+		return
+	}
+
+	numGoroutines := stress.Goroutines
+	if numGoroutines <= 0 {
+		numGoroutines = 8
+	}
+
+	numOperations := stress.Operations
+	if numOperations <= 0 {
+		numOperations = 100
+	}
+
+	timeout := stress.Timeout
+	if timeout <= 0 {
+		timeout = Timeout
+	}
+
+	group, register := stress.NewGroup()
+
+	state := stressState{
+		t:t,
+		group:group,
+		register:register,
+		toilCh:make(chan interface{}, 1),
+	}
+
+	var waitGroup sync.WaitGroup
+	for i:=0; i<numGoroutines; i++ {
+		waitGroup.Add(1)
+		go func(goroutine int) {
+			defer waitGroup.Done()
+
+			state.animate(goroutine, rand.New(rand.NewSource(stress.Seed+int64(goroutine))), numOperations)
+		}(i)
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(timeout):
+		t.Errorf("toiltest: Stress (seed %d): expected the operations to finish within %v, but they did not (deadlock?).\n\n%s", stress.Seed, timeout, allStacks())
+		return
+	}
+
+	// Make the Toil method get called (if it was not already), and make all the
+	// registered toilers return, so that the Toil method returns.
+	state.toil()
+
+	deadline := time.After(timeout)
+	ticker   := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	loop: for {
+		select {
+		case panicValue := <-state.toilCh:
+			if nil != panicValue {
+				t.Errorf("toiltest: Stress (seed %d): expected Toil() to not panic(), but it panic()ed with: %v", stress.Seed, panicValue)
+			}
+			break loop
+		case <-ticker.C:
+			state.terminateAll()
+		case <-deadline:
+			t.Errorf("toiltest: Stress (seed %d): expected Toil() to return within %v, but it did not (deadlock?).\n\n%s", stress.Seed, timeout, allStacks())
+			return
+		}
+	}
+
+	state.checkLen()
+}
+
+
+// animate does `n` (pseudo) randomly chosen operations.
+//
+// Each goroutine only makes the toilers it registered panic() or return, so that no other
+// goroutine can make them stop toiling between it checking that they are toiling, and it
+// making them panic() or return.
+func (state *stressState) animate(goroutine int, randomness *rand.Rand, n int) {
+
+	var recorders []*ToilRecorder
+
+	for operation:=0; operation<n; operation++ {
+		switch choice := randomness.Intn(100); {
+		case choice < 30:
+			recorder := NewRecorder()
+			recorders = append(recorders, recorder)
+
+			state.mutex.Lock()
+			state.recorders = append(state.recorders, recorder)
+			state.numRegistering++
+			state.mutex.Unlock()
+
+			state.register(recorder)
+
+			state.mutex.Lock()
+			state.numRegistered++
+			state.mutex.Unlock()
+		case choice < 50:
+			state.checkLen()
+		case choice < 60:
+			state.toil()
+		case choice < 65:
+			state.group.Stop()
+		case choice < 80:
+			if recorder := toiling(recorders, randomness); nil != recorder {
+				if !recorder.PanicAndWait(stressPanic{goroutine:goroutine, operation:operation}) {
+					state.t.Errorf("toiltest: Stress: expected the toil.Group to observe the panic() within %v, but it did not.", Timeout)
+				}
+			}
+		default:
+			if recorder := toiling(recorders, randomness); nil != recorder {
+				recorder.Terminate()
+			}
+		}
+
+		for _,recorder := range recorders {
+			if numToiling := recorder.NumToiling(); numToiling < 0 {
+				state.t.Errorf("toiltest: Stress: expected the number of active calls to Toil() to never be negative, but actually was %d.", numToiling)
+			}
+		}
+	}
+}
+
+
+// terminateAll makes all the registered toilers that are toiling return.
+//
+// NOTE that this must only be called once all the goroutines doing operations are done.
+func (state *stressState) terminateAll() {
+	state.mutex.Lock()
+	recorders := state.recorders
+	state.mutex.Unlock()
+
+	for _,recorder := range recorders {
+		for 0 < recorder.NumToiling() {
+			recorder.Terminate()
+		}
+	}
+}
+
+
+// toiling returns a (pseudo) randomly chosen recorder that is toiling. (Or nil.)
+func toiling(recorders []*ToilRecorder, randomness *rand.Rand) *ToilRecorder {
+	var candidates []*ToilRecorder
+	for _,recorder := range recorders {
+		if 0 < recorder.NumToiling() {
+			candidates = append(candidates, recorder)
+		}
+	}
+
+	if 0 == len(candidates) {
+		return nil
+	}
+
+	return candidates[randomness.Intn(len(candidates))]
+}
+
+
+// toil calls the Toil method (in its own goroutine), if it has not been called already.
+func (state *stressState) toil() {
+	state.toilOnce.Do(func(){
+		go func() {
+			var panicValue interface{}
+			defer func() {
+				if recovered := recover(); nil != recovered {
+					panicValue = recovered
+				}
+				state.toilCh <- panicValue
+			}()
+
+			state.group.Toil()
+		}()
+	})
+}
+
+
+// checkLen checks the invariants about the Len method.
+//
+// NOTE that the mutex is kept locked while calling the Len method, so that the checks
+// of concurrent calls do not interfere with each other.
+func (state *stressState) checkLen() {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	lowest  := state.numRegistered
+	length  := state.group.Len()
+	highest := state.numRegistering
+
+	if length < lowest || highest < length {
+		state.t.Errorf("toiltest: Stress: expected Len() to be between %d and %d, but actually was %d.", lowest, highest, length)
+	}
+
+	if length < state.maxLen {
+		state.t.Errorf("toiltest: Stress: expected Len() to never go down, but it went from %d to %d.", state.maxLen, length)
+	}
+
+	if state.maxLen < length {
+		state.maxLen = length
+	}
+}
+
+
+// allStacks returns the stack traces of all the goroutines.
+func allStacks() string {
+	buffer := make([]byte, 1<<20)
+	buffer = buffer[:runtime.Stack(buffer, true)]
+
+	return strings.TrimSpace(string(buffer))
+}
@@ -0,0 +1,41 @@
+package toiltest_test
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil"
+	"github.com/reiver/go-toil/toiltest"
+)
+
+
+func TestStress(t *testing.T) {
+
+	for seed:=int64(1); seed<=10; seed++ {
+		toiltest.Stress{
+			Seed: seed,
+			NewGroup: func() (toiltest.Grouper, func(*toiltest.ToilRecorder)) {
+				group := toil.NewGroup(toil.Isolate)
+
+				return group, func(recorder *toiltest.ToilRecorder) {
+					group.Register(recorder)
+				}
+			},
+		}.Run(t)
+	}
+}
+
+
+func TestStressMaxConcurrency(t *testing.T) {
+
+	toiltest.Stress{
+		Seed: 42,
+		NewGroup: func() (toiltest.Grouper, func(*toiltest.ToilRecorder)) {
+			group := toil.NewGroup(toil.Isolate, toil.MaxConcurrency(3), toil.RestartOnPanic)
+
+			return group, func(recorder *toiltest.ToilRecorder) {
+				group.Register(recorder)
+			}
+		},
+	}.Run(t)
+}
@@ -1,6 +1,68 @@
 package toil
 
 
+import (
+	"sync"
+)
+
+
 type waiter interface {
 	Wait()
 }
+
+
+// runCounter counts the runs of toilers that have not finished yet.
+//
+// It is like a sync.WaitGroup, except that it is OK to call Add (when the count is
+// zero) at the same time as Wait. (Which happens when a toiler is registered with a
+// Group at the same time as the Group's Toil method is returning, because all its
+// other toilers finished.)
+//
+// The zero value is ready to use.
+type runCounter struct {
+	mutex  sync.Mutex
+	count  int
+	zeroCh chan struct{} // NOTE that this is closed whenever count is zero. (Or is nil.)
+}
+
+
+// Add adds `delta` to the count.
+func (counter *runCounter) Add(delta int) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	before := counter.count
+	counter.count += delta
+
+	switch {
+	case counter.count < 0:
+		panic("toil: negative run counter")
+	case 0 == counter.count:
+		if nil != counter.zeroCh {
+			close(counter.zeroCh)
+			counter.zeroCh = nil
+		}
+	case 0 == before:
+		counter.zeroCh = make(chan struct{})
+	}
+}
+
+
+// Done subtracts one from the count.
+func (counter *runCounter) Done() {
+	counter.Add(-1)
+}
+
+
+// Wait blocks until the count is zero.
+func (counter *runCounter) Wait() {
+	counter.mutex.Lock()
+	zeroCh := counter.zeroCh
+	counter.mutex.Unlock()
+
+	if nil == zeroCh {
+		return
+	}
+
+	<-zeroCh
+}
@@ -0,0 +1,161 @@
+package toil
+
+
+import (
+	"io"
+	"time"
+)
+
+
+// Watchdog returns a GroupOption that makes the Group watch its toilers' heartbeats
+// (see Heartbeat), and flag any toiler whose last heartbeat is older than `threshold`
+// as hung. Hung toilers are surfaced in the Group's Status.
+//
+// What else the Group does with a hung toiler is decided by the WatchdogAction(s).
+// For example:
+//
+//	group := toil.NewGroup(toil.Watchdog(time.Minute, toil.WatchdogRestart, toil.WatchdogDumpStacks(os.Stderr)))
+func Watchdog(threshold time.Duration, actions ...WatchdogAction) GroupOption {
+	option := watchdogOption{
+		threshold:threshold,
+	}
+
+	for _,action := range actions {
+		if nil == action {
+			continue
+		}
+		action.applyWatchdogAction(&option)
+	}
+
+	return option
+}
+
+
+// WatchdogAction is something the Group's Watchdog does when it flags a toiler as hung.
+type WatchdogAction interface {
+	applyWatchdogAction(*watchdogOption)
+}
+
+
+// WatchdogRestart is a WatchdogAction that makes the Group restart a hung toiler.
+//
+// The hung run's context is cancelled, and the Group stops waiting on it, and starts
+// a new run of the toiler. (Since the hung run is hung, it might never return. If it
+// does return, how it returned is not reported.)
+var WatchdogRestart WatchdogAction = watchdogRestartAction{}
+
+
+// WatchdogDumpStacks returns a WatchdogAction that makes the Group write the goroutine
+// stacks of its toilers to `writer`, when it flags a toiler as hung. (See the Group's
+// DumpStacks method.)
+//
+// The stacks are written to `writer` on a goroutine of their own, so that a slow writer
+// does not hold up the Group. If the stacks from an earlier time are still being written,
+// then the stacks from this time are dropped.
+func WatchdogDumpStacks(writer io.Writer) WatchdogAction {
+	return watchdogDumpStacksAction{
+		writer:writer,
+	}
+}
+
+
+type watchdogOption struct {
+	threshold  time.Duration
+	restart    bool
+	dumpWriter io.Writer
+}
+
+
+func (option watchdogOption) applyGroupOption(config *groupConfig) {
+	config.watchdog = option
+}
+
+
+type watchdogRestartAction struct{}
+
+
+func (watchdogRestartAction) applyWatchdogAction(option *watchdogOption) {
+	option.restart = true
+}
+
+
+type watchdogDumpStacksAction struct {
+	writer io.Writer
+}
+
+
+func (action watchdogDumpStacksAction) applyWatchdogAction(option *watchdogOption) {
+	option.dumpWriter = action.writer
+}
+
+
+// tickCh returns the channel the daemon receives on, to know when to
+// check its toilers' heartbeats, and a func that stops the ticker (which the
+// daemon calls when it is stopped).
+//
+// It returns nil (which blocks forever) if there is no watchdog.
+func (option watchdogOption) tickCh(clock Clock) (<-chan time.Time, func()) {
+	if option.threshold <= 0 {
+		return nil, func(){}
+	}
+
+	interval := option.threshold / 2
+	if interval <= 0 {
+		interval = option.threshold
+	}
+
+	return clock.NewTicker(interval)
+}
+
+
+// watch is called periodically by the daemon (on its goroutine) to check the
+// heartbeats of its registered toilers.
+func (daemon *internalGroupDaemon) watch(registrations []*registeredToiler) {
+
+	option := daemon.config.watchdog
+
+	now := daemon.config.clock.Now()
+
+	var hungRuns []*toilRun
+	for _,registration := range registrations {
+		run := registration.flagHung(now, option.threshold)
+		if nil == run {
+			continue
+		}
+
+		hungRuns = append(hungRuns, run)
+	}
+
+	if 0 == len(hungRuns) {
+		return
+	}
+
+	if nil != option.dumpWriter {
+		daemon.dumpStacksInBackground(option.dumpWriter)
+	}
+
+	if option.restart {
+		for _,run := range hungRuns {
+			daemon.restartHung(run)
+		}
+	}
+}
+
+
+// restartHung stops waiting on a (hung) toiler's run, and starts a new run of the toiler.
+func (daemon *internalGroupDaemon) restartHung(run *toilRun) {
+
+	// If the run has already finished, then there is nothing to restart.
+	if !run.report() {
+		return
+	}
+
+	run.cancel()
+
+	// NOTE that we spawn the new run before we release the old one, so that
+	// the run counter does not (even briefly) get to zero. And that the old run
+	// keeps its slot (see watchTimeout) until it actually returns.
+	daemon.restart(run.registration)
+
+	run.releaseCount()
+}
@@ -0,0 +1,224 @@
+package toil
+
+
+import (
+	"testing"
+
+	"github.com/reiver/go-toil/toiltest"
+
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *syncBuffer) Write(p []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+
+	return buffer.buffer.Write(p)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+
+	return buffer.buffer.String()
+}
+
+
+// eventually calls fn until it returns true, or until the timeout.
+func eventually(timeout time.Duration, fn func() bool) bool {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if fn() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return fn()
+}
+
+
+func TestHeartbeatFromContext(t *testing.T) {
+
+	if heartbeat := HeartbeatFromContext(context.Background()); nil != heartbeat {
+		t.Errorf("Expected no heartbeat, but actually got: %v", heartbeat)
+		return
+	}
+
+	// Should not panic().
+	var heartbeat *Heartbeat
+	heartbeat.Beat()
+
+	begin := time.Now()
+	heartbeat = newHeartbeat(realClock{})
+
+	ctx := withHeartbeat(context.Background(), heartbeat)
+	if expected, actual := heartbeat, HeartbeatFromContext(ctx); expected != actual {
+		t.Errorf("Expected heartbeat %p, but actually got %p.", expected, actual)
+		return
+	}
+
+	heartbeat.Beat()
+	if last := heartbeat.Last(); last.Before(begin) {
+		t.Errorf("Expected last heartbeat to be after %v, but actually was %v.", begin, last)
+		return
+	}
+}
+
+
+func TestWatchdogHung(t *testing.T) {
+
+	dump := new(syncBuffer)
+
+	group := NewGroup(Watchdog(20*time.Millisecond, WatchdogDumpStacks(dump)))
+
+	group.Register( ContextToilerFunc(func(ctx context.Context){
+		HeartbeatFromContext(ctx).Beat()
+		<-ctx.Done()
+	}) )
+	group.Register( ContextToilerFunc(func(ctx context.Context){
+		// This toiler never gets its heartbeat, so is not watched.
+		<-ctx.Done()
+	}) )
+
+	go group.Toil()
+	defer group.Stop()
+
+	if !eventually(5*time.Second, func() bool { return group.Status()[0].Hung }) {
+		t.Errorf("Expected the toiler to be flagged as hung, but it was not.")
+		return
+	}
+
+	statuses := group.Status()
+
+	if statuses[0].LastHeartbeat.IsZero() {
+		t.Errorf("Expected the hung toiler's last heartbeat to be set, but it was not.")
+	}
+
+	if statuses[1].Hung {
+		t.Errorf("Expected the toiler that did not get its heartbeat to not be flagged as hung, but it was.")
+	}
+	if !statuses[1].LastHeartbeat.IsZero() {
+		t.Errorf("Expected the toiler that did not get its heartbeat to not have a last heartbeat, but actually had %v.", statuses[1].LastHeartbeat)
+	}
+
+	if !eventually(5*time.Second, func() bool { return strings.Contains(dump.String(), "TestWatchdogHung") }) {
+		t.Errorf("Expected goroutine stacks to be dumped, but actually got: %q", dump.String())
+	}
+}
+
+
+// blockedWriter is a writer whose Write method blocks until unblockCh is closed.
+type blockedWriter struct {
+	writingCh chan struct{}
+	unblockCh chan struct{}
+}
+
+
+func (writer blockedWriter) Write(p []byte) (int, error) {
+	select {
+	case writer.writingCh <- struct{}{}:
+	default:
+	}
+	<-writer.unblockCh
+	return len(p), nil
+}
+
+
+func TestWatchdogDumpStacksBlockedWriter(t *testing.T) {
+
+	writer := blockedWriter{
+		writingCh:make(chan struct{}, 1),
+		unblockCh:make(chan struct{}),
+	}
+	defer close(writer.unblockCh)
+
+	group := NewGroup(Watchdog(20*time.Millisecond, WatchdogDumpStacks(writer)))
+
+	group.Register( ContextToilerFunc(func(ctx context.Context){
+		HeartbeatFromContext(ctx).Beat()
+		<-ctx.Done()
+	}) )
+
+	go group.Toil()
+	defer group.Stop()
+
+	select {
+	case <-writer.writingCh:
+	case <-time.After(5*time.Second):
+		t.Fatalf("Expected the goroutine stacks to be written, but they were not.")
+	}
+
+	// The writer is blocked, but the Group must not be.
+	doneCh := make(chan struct{})
+	go func() {
+		group.Len()
+		group.Status()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(5*time.Second):
+		t.Errorf("Expected the Group to answer while the stacks are being written, but it did not.")
+	}
+}
+
+
+func TestWatchdogRestart(t *testing.T) {
+
+	var numRuns int64
+
+	group := NewGroup(Watchdog(20*time.Millisecond, WatchdogRestart))
+
+	group.Register( ContextToilerFunc(func(ctx context.Context){
+		heartbeat := HeartbeatFromContext(ctx)
+
+		// The first run hangs (until it is cancelled).
+		if 1 == atomic.AddInt64(&numRuns, 1) {
+			<-ctx.Done()
+			return
+		}
+
+		heartbeat.Beat()
+	}) )
+
+	returned, panicValue := toilWithin(group, 5*time.Second)
+	if !returned {
+		t.Errorf("Expected Toil() to return, but it did not. (Panic value: %v)", panicValue)
+		return
+	}
+
+	if expected, actual := 2, group.Status()[0].Runs; expected != actual {
+		t.Errorf("Expected number of runs to be %d, but actually was %d.", expected, actual)
+	}
+}
+
+
+func TestWatchdogStopsTicker(t *testing.T) {
+
+	clock := toiltest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	group := NewGroup(Watchdog(time.Minute), WithClock(clock))
+
+	// The watchdog's ticker.
+	clock.BlockUntilWaiters(1)
+
+	group.Stop()
+
+	if !eventually(5*time.Second, func() bool { return 0 == clock.NumWaiters() }) {
+		t.Errorf("Expected the watchdog's ticker to be stopped when the Group is stopped, but actually %d waiters were left.", clock.NumWaiters())
+	}
+}